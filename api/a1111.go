@@ -0,0 +1,202 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"severless-task-scheduler/db/model"
+	"severless-task-scheduler/db/repository"
+	"strings"
+)
+
+const (
+	DefaultSamplerName = "Euler a"
+	DefaultBatchSize   = 1
+)
+
+// A1111Request Stable Diffusion WebUI (AUTOMATIC1111) 的 /sdapi/v1/txt2img 请求
+// img2img 和 inpaint 发送到同一目录下的 img2img 接口，模型的 Api 需要以 /sdapi/v1/txt2img 结尾
+type A1111Request struct {
+	Prompt         string `json:"prompt"`
+	NegativePrompt string `json:"negative_prompt"`
+	Steps          int    `json:"steps"`
+	CfgScale       int    `json:"cfg_scale"`
+	Width          int    `json:"width"`
+	Height         int    `json:"height"`
+	Seed           int    `json:"seed"`
+	SamplerName    string `json:"sampler_name"`
+	BatchSize      int    `json:"batch_size"`
+
+	InitImages        []string `json:"init_images,omitempty"`
+	Mask              string   `json:"mask,omitempty"`
+	DenoisingStrength *float64 `json:"denoising_strength,omitempty"`
+
+	taskType string
+}
+
+// A1111Response txt2img 的响应，images 为不带 data url 前缀的 base64 图片
+type A1111Response struct {
+	Images []string `json:"images"`
+	Info   string   `json:"info"`
+}
+
+func (a *A1111Request) Json() []byte {
+	marshal, _ := json.Marshal(a)
+	return marshal
+}
+
+func (a *A1111Request) Parse(r io.Reader, task *model.Task) error {
+	all, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	parameter := TaskParameter{}
+	err = json.Unmarshal(all, &parameter)
+	if err != nil {
+		return err
+	}
+	a.Prompt = parameter.Prompt
+	a.NegativePrompt = DefaultNegativePrompt
+	a.Steps = DefaultNumInferenceSteps
+	a.CfgScale = DefaultGuidanceScale
+	a.Width = DefaultWidth
+	a.Height = DefaultHeight
+	a.Seed = DefaultRandSeed
+	a.SamplerName = DefaultSamplerName
+	a.BatchSize = DefaultBatchSize
+	if parameter.NegativePrompt != nil {
+		a.NegativePrompt = *parameter.NegativePrompt
+	}
+	if parameter.NumInferenceSteps != nil {
+		a.Steps = *parameter.NumInferenceSteps
+	}
+	if parameter.GuidanceScale != nil {
+		a.CfgScale = *parameter.GuidanceScale
+	}
+	if parameter.Width != nil {
+		a.Width = *parameter.Width
+	}
+	if parameter.Height != nil {
+		a.Height = *parameter.Height
+	}
+	if parameter.RandSeed != nil {
+		a.Seed = *parameter.RandSeed
+	}
+	if parameter.SamplerName != nil {
+		a.SamplerName = *parameter.SamplerName
+	}
+	if parameter.BatchSize != nil {
+		a.BatchSize = *parameter.BatchSize
+	}
+	a.taskType = TaskTypeTxt2Img
+	if parameter.TaskType != nil && *parameter.TaskType != TaskTypeTxt2Img {
+		a.taskType = *parameter.TaskType
+		a.DenoisingStrength = parameter.Strength
+		if a.DenoisingStrength == nil {
+			strength := DefaultStrength
+			a.DenoisingStrength = &strength
+		}
+	}
+	return nil
+}
+
+func (a *A1111Request) SetInputs(inputs []*model.TaskInput) {
+	if input := findInput(inputs, InputInitImage); input != nil {
+		a.InitImages = []string{dataURL(input)}
+	}
+	if input := findInput(inputs, InputMask); input != nil {
+		a.Mask = dataURL(input)
+	}
+}
+
+// CacheKey 固定随机种子时结果只由参数决定
+func (a *A1111Request) CacheKey() ([]byte, bool) {
+	if a.Seed < 0 {
+		return nil, false
+	}
+	return a.Json(), true
+}
+
+// Endpoint img2img 和 inpaint 使用 img2img 接口
+func (a *A1111Request) Endpoint(api string) string {
+	if a.taskType == TaskTypeTxt2Img || !strings.HasSuffix(api, "/txt2img") {
+		return api
+	}
+	return strings.TrimSuffix(api, "txt2img") + "img2img"
+}
+
+// Decode 取出响应中的图片
+func (a *A1111Request) Decode(message []byte) ([]string, error) {
+	response := A1111Response{}
+	err := json.Unmarshal(message, &response)
+	if err != nil {
+		return nil, err
+	}
+	return response.Images, nil
+}
+
+func (a *A1111Request) Schema() *Schema {
+	return &Schema{
+		Type: "object",
+		Properties: map[string]*Property{
+			"model": {
+				Type: "string",
+			},
+			"prompt": {
+				Type:      "string",
+				MinLength: IntPtr(1),
+				MaxLength: IntPtr(MaxPromptLength),
+			},
+			"negative_prompt": {
+				Type:      "string",
+				MaxLength: IntPtr(MaxPromptLength),
+				Default:   DefaultNegativePrompt,
+			},
+			"num_inference_steps": {
+				Type:    "integer",
+				Minimum: IntPtr(1),
+				Maximum: IntPtr(150),
+				Default: DefaultNumInferenceSteps,
+			},
+			"width": {
+				Type:       "integer",
+				Minimum:    IntPtr(64),
+				Maximum:    IntPtr(2048),
+				MultipleOf: IntPtr(8),
+				Default:    DefaultWidth,
+			},
+			"height": {
+				Type:       "integer",
+				Minimum:    IntPtr(64),
+				Maximum:    IntPtr(2048),
+				MultipleOf: IntPtr(8),
+				Default:    DefaultHeight,
+			},
+			"guidance_scale": {
+				Type:    "integer",
+				Minimum: IntPtr(1),
+				Maximum: IntPtr(30),
+				Default: DefaultGuidanceScale,
+			},
+			"rand_seed": {
+				Type:    "integer",
+				Minimum: IntPtr(-1),
+				Maximum: IntPtr(math.MaxInt32),
+				Default: DefaultRandSeed,
+			},
+			"sampler_name": {
+				Type:      "string",
+				MinLength: IntPtr(1),
+				MaxLength: IntPtr(64),
+				Default:   DefaultSamplerName,
+			},
+			"batch_size": {
+				Type:    "integer",
+				Minimum: IntPtr(1),
+				Maximum: IntPtr(repository.MaxImages),
+				Default: DefaultBatchSize,
+			},
+		},
+		Required: []string{"model", "prompt"},
+	}
+}
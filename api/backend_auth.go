@@ -0,0 +1,70 @@
+package api
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"severless-task-scheduler/config"
+)
+
+// backendHeader 生成连接模型后端的请求头，每次连接时重新读取引用的密钥
+func backendHeader(m Model) (http.Header, error) {
+	header := http.Header{}
+	for key, value := range m.Headers {
+		resolved, err := config.Secret(value).Resolve()
+		if err != nil {
+			return nil, fmt.Errorf("header %s: %v", key, err)
+		}
+		if resolved != "" {
+			header.Set(key, resolved)
+		}
+	}
+	switch {
+	case m.Auth.BearerToken != "":
+		token, err := m.Auth.BearerToken.Resolve()
+		if err != nil {
+			return nil, fmt.Errorf("bearer token: %v", err)
+		}
+		header.Set("Authorization", "Bearer "+token)
+	case m.Auth.Username != "":
+		password, err := m.Auth.Password.Resolve()
+		if err != nil {
+			return nil, fmt.Errorf("password: %v", err)
+		}
+		request := http.Request{Header: header}
+		request.SetBasicAuth(m.Auth.Username, password)
+	}
+	return header, nil
+}
+
+// backendTLS 生成 wss 连接的 TLS 配置，没有配置时返回 nil 使用默认配置
+func backendTLS(m Model) (*tls.Config, error) {
+	if m.TLS.CAFile == "" && m.TLS.CertFile == "" {
+		return nil, nil
+	}
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if m.TLS.CAFile != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		pem, err := os.ReadFile(m.TLS.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read ca file: %v", err)
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in ca file %s", m.TLS.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if m.TLS.CertFile != "" {
+		certificate, err := tls.LoadX509KeyPair(m.TLS.CertFile, m.TLS.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+	return tlsConfig, nil
+}
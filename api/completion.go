@@ -0,0 +1,113 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"severless-task-scheduler/db/model"
+)
+
+const (
+	DefaultMaxTokens   = 256
+	DefaultTemperature = 1.0
+
+	MaxCompletionPromptLength = 32000
+	MaxCompletionTokens       = 4096
+)
+
+// CompletionRequest OpenAI 兼容的 /v1/completions 请求，model 为任务参数中的模型名称
+type CompletionRequest struct {
+	Model       string  `json:"model"`
+	Prompt      string  `json:"prompt"`
+	MaxTokens   int     `json:"max_tokens"`
+	Temperature float64 `json:"temperature"`
+}
+
+// CompletionResponse completions 的响应，usage 原样保存为 json 输出
+type CompletionResponse struct {
+	Choices []struct {
+		Text string `json:"text"`
+	} `json:"choices"`
+	Usage json.RawMessage `json:"usage"`
+}
+
+func (c *CompletionRequest) Json() []byte {
+	marshal, _ := json.Marshal(c)
+	return marshal
+}
+
+func (c *CompletionRequest) Parse(r io.Reader, task *model.Task) error {
+	all, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	parameter := TaskParameter{}
+	err = json.Unmarshal(all, &parameter)
+	if err != nil {
+		return err
+	}
+	c.Model = parameter.Model
+	c.Prompt = parameter.Prompt
+	c.MaxTokens = DefaultMaxTokens
+	c.Temperature = DefaultTemperature
+	if parameter.MaxTokens != nil {
+		c.MaxTokens = *parameter.MaxTokens
+	}
+	if parameter.Temperature != nil {
+		c.Temperature = *parameter.Temperature
+	}
+	return nil
+}
+
+func (c *CompletionRequest) Outputs() []string {
+	return []string{OutputText, OutputJSON}
+}
+
+// DecodeArtifacts 每个 choice 产生一个文本输出，usage 产生一个 json 输出
+func (c *CompletionRequest) DecodeArtifacts(message []byte) ([]Artifact, error) {
+	response := CompletionResponse{}
+	err := json.Unmarshal(message, &response)
+	if err != nil {
+		return nil, err
+	}
+	if len(response.Choices) == 0 {
+		return nil, errors.New("no choices in response")
+	}
+	artifacts := make([]Artifact, 0, len(response.Choices)+1)
+	for _, choice := range response.Choices {
+		artifacts = append(artifacts, Artifact{Kind: OutputText, MimeType: "text/plain; charset=utf-8", Data: []byte(choice.Text)})
+	}
+	if len(response.Usage) > 0 && string(response.Usage) != "null" {
+		artifacts = append(artifacts, Artifact{Kind: OutputJSON, MimeType: "application/json", Data: response.Usage})
+	}
+	return artifacts, nil
+}
+
+func (c *CompletionRequest) Schema() *Schema {
+	return &Schema{
+		Type: "object",
+		Properties: map[string]*Property{
+			"model": {
+				Type: "string",
+			},
+			"prompt": {
+				Type:      "string",
+				MinLength: IntPtr(1),
+				MaxLength: IntPtr(MaxCompletionPromptLength),
+			},
+			"max_tokens": {
+				Type:    "integer",
+				Minimum: IntPtr(1),
+				Maximum: IntPtr(MaxCompletionTokens),
+				Default: DefaultMaxTokens,
+			},
+			"temperature": {
+				Type:    "number",
+				Minimum: IntPtr(0),
+				Maximum: IntPtr(2),
+				Default: DefaultTemperature,
+			},
+		},
+		Required: []string{"model", "prompt"},
+	}
+}
@@ -0,0 +1,352 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+	"severless-task-scheduler/config"
+	"severless-task-scheduler/logging"
+	"severless-task-scheduler/metrics"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	ReconnectMinBackoff = time.Second
+	ReconnectMaxBackoff = time.Minute
+	DialTimeout         = 30 * time.Second
+	RetireCheckPeriod   = time.Second
+)
+
+// backend 一个模型后端的连接及其状态
+// 每个连接由一个读协程持续读取，以便及时处理 pong；同一时间只允许一个调用使用连接
+type backend struct {
+	mu         sync.RWMutex
+	connection *websocket.Conn
+	messages   chan message
+	lastPong   time.Time
+	inflight   int64
+	model      Model
+	transport  Transport
+	// stop 关闭后 keepalive 停止心跳和重连
+	stop chan struct{}
+
+	callMu sync.Mutex
+}
+
+type message struct {
+	messageType int
+	data        []byte
+	err         error
+}
+
+// session 一次独占连接的调用
+type session struct {
+	b          *backend
+	connection *websocket.Conn
+	messages   <-chan message
+}
+
+var errNoConnection = errors.New("no connection")
+
+// BackendState 模型后端连接状态的快照
+type BackendState struct {
+	Transport string     `json:"transport"`
+	Connected bool       `json:"connected"`
+	LastPong  *time.Time `json:"last_pong"`
+	Inflight  int64      `json:"inflight"`
+}
+
+var backendsMu sync.RWMutex
+var backends = make(map[string]*backend)
+
+// connect 连接所有模型并保持心跳，连接失败的模型会在后台重试
+func connect(models map[string]Model) {
+	for name, m := range models {
+		startBackend(name, m)
+	}
+}
+
+// Close 停止所有后端的心跳并断开连接，需要在 Drain 之后调用，否则执行中的调用会失败
+func Close() {
+	backendsMu.Lock()
+	closing := backends
+	backends = make(map[string]*backend)
+	backendsMu.Unlock()
+	for name, b := range closing {
+		b.close()
+		logrus.WithField(logging.FieldModel, name).Info("model api disconnected")
+	}
+}
+
+// startBackend 为模型创建新的后端并连接，替换同名的后端。http 后端不需要保持连接
+func startBackend(name string, m Model) *backend {
+	b := &backend{model: m, stop: make(chan struct{})}
+	if m.Transport == config.TransportHTTP {
+		b.transport = newHTTPTransport(m)
+		if err := b.transport.(*httpTransport).err; err != nil {
+			logrus.WithField(logging.FieldModel, name).WithError(err).Error("create model http transport error")
+		}
+		backendsMu.Lock()
+		backends[name] = b
+		backendsMu.Unlock()
+		return b
+	}
+	b.transport = &websocketTransport{b: b}
+	connection, err := dial(m, b)
+	if err != nil {
+		logrus.WithField(logging.FieldModel, name).WithError(err).Error("connect model api error")
+	} else {
+		b.setConnection(connection)
+	}
+	backendsMu.Lock()
+	backends[name] = b
+	backendsMu.Unlock()
+	go keepalive(name, m, b, connection)
+	return b
+}
+
+// retireBackend 移除后端，等已派发的调用全部结束后再停止心跳并断开连接
+func retireBackend(name string, b *backend) {
+	backendsMu.Lock()
+	if backends[name] == b {
+		delete(backends, name)
+	}
+	backendsMu.Unlock()
+	go func() {
+		for atomic.LoadInt64(&b.inflight) > 0 {
+			time.Sleep(RetireCheckPeriod)
+		}
+		b.close()
+		logrus.WithField(logging.FieldModel, name).Info("model api disconnected")
+	}()
+}
+
+// close 停止心跳并断开连接，http 后端关闭空闲的连接
+func (b *backend) close() {
+	close(b.stop)
+	if t, ok := b.transport.(*httpTransport); ok {
+		t.client.CloseIdleConnections()
+	}
+	if connection := b.getConnection(); connection != nil {
+		_ = connection.Close()
+	}
+	b.setConnection(nil)
+}
+
+func dial(m Model, b *backend) (*websocket.Conn, error) {
+	header, err := backendHeader(m)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig, err := backendTLS(m)
+	if err != nil {
+		return nil, err
+	}
+	dialer := websocket.Dialer{HandshakeTimeout: DialTimeout, TLSClientConfig: tlsConfig}
+	connection, _, err := dialer.Dial(m.Api, header)
+	if err != nil {
+		return nil, err
+	}
+	connection.SetPongHandler(
+		func(string) error {
+			b.pong()
+			return connection.SetReadDeadline(time.Now().Add(m.ReadTimeout.Duration()))
+		},
+	)
+	return connection, nil
+}
+
+// keepalive 定时发送心跳，心跳失败或尚未连接时按指数退避重新连接
+func keepalive(name string, m Model, b *backend, connection *websocket.Conn) {
+	period := m.HeartbeatPeriod.Duration()
+	timer := time.NewTicker(period)
+	defer timer.Stop()
+	backoff := ReconnectMinBackoff
+	for {
+		if connection == nil {
+			select {
+			case <-b.stop:
+				return
+			case <-time.After(backoff):
+			}
+			var err error
+			connection, err = dial(m, b)
+			select {
+			case <-b.stop:
+				// 后端已被移除，丢弃新建立的连接
+				if err == nil {
+					_ = connection.Close()
+				}
+				return
+			default:
+			}
+			if err != nil {
+				logrus.WithField(logging.FieldModel, name).WithError(err).Error("reconnect model api error")
+				backoff *= 2
+				if backoff > ReconnectMaxBackoff {
+					backoff = ReconnectMaxBackoff
+				}
+				continue
+			}
+			backoff = ReconnectMinBackoff
+			b.setConnection(connection)
+			metrics.WebsocketReconnects.WithLabelValues(name).Inc()
+			logrus.WithField(logging.FieldModel, name).Info("model api reconnected")
+		}
+
+		select {
+		case <-b.stop:
+			return
+		case <-timer.C:
+		}
+		// WriteControl 可以与调用中的 WriteMessage 并发执行
+		err := connection.WriteControl(websocket.PingMessage, nil, time.Now().Add(period))
+		if err != nil {
+			logrus.WithField(logging.FieldModel, name).WithError(err).Error("ping model api error")
+			metrics.PingFailures.WithLabelValues(name).Inc()
+			_ = connection.Close()
+			b.setConnection(nil)
+			connection = nil
+		}
+	}
+}
+
+func (b *backend) setConnection(connection *websocket.Conn) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.connection = connection
+	b.messages = nil
+	if connection != nil {
+		b.messages = make(chan message, 1)
+		go read(connection, b.messages, b.model)
+	}
+}
+
+// read 持续读取连接上的消息，没有调用在等待的消息会被丢弃。读取出错时关闭连接，由 keepalive 重新连接
+func read(connection *websocket.Conn, messages chan<- message, m Model) {
+	connection.SetReadLimit(m.MaxReadSize)
+	connection.SetReadDeadline(time.Now().Add(m.ReadTimeout.Duration()))
+	for {
+		messageType, data, err := connection.ReadMessage()
+		select {
+		case messages <- message{messageType: messageType, data: data, err: err}:
+		default:
+			logrus.Warn("drop unexpected model api message")
+		}
+		if err != nil {
+			_ = connection.Close()
+			return
+		}
+	}
+}
+
+// begin 独占后端的连接，没有可用连接时返回 errNoConnection
+func (b *backend) begin() (*session, error) {
+	b.callMu.Lock()
+	b.mu.RLock()
+	connection, messages := b.connection, b.messages
+	b.mu.RUnlock()
+	if connection == nil {
+		b.callMu.Unlock()
+		return nil, errNoConnection
+	}
+	// 丢弃上一次超时的调用遗留的消息
+	select {
+	case <-messages:
+	default:
+	}
+	return &session{b: b, connection: connection, messages: messages}, nil
+}
+
+func (s *session) write(payload []byte) error {
+	return s.connection.WriteMessage(websocket.TextMessage, payload)
+}
+
+// receive 等待模型返回一条消息
+func (s *session) receive(timeout time.Duration) (int, []byte, error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case m := <-s.messages:
+		return m.messageType, m.data, m.err
+	case <-timer.C:
+		return 0, nil, fmt.Errorf("no response in %s", timeout)
+	}
+}
+
+func (s *session) end() {
+	s.b.callMu.Unlock()
+}
+
+func (b *backend) getConnection() *websocket.Conn {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.connection
+}
+
+func (b *backend) pong() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.lastPong = time.Now()
+}
+
+func (b *backend) state() BackendState {
+	state := BackendState{
+		Transport: b.model.Transport,
+		Connected: b.transport.Connected(),
+		Inflight:  atomic.LoadInt64(&b.inflight),
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if !b.lastPong.IsZero() {
+		lastPong := b.lastPong
+		state.LastPong = &lastPong
+	}
+	return state
+}
+
+// lookupAndTrack 查找模型的后端并记录一次已派发的调用，返回的函数在调用结束时执行。
+// 计数在持有 backendsMu 时增加，retireBackend 移除后端后不会再有新的调用被派发到该后端
+func lookupAndTrack(modelName string) (*backend, func(), bool) {
+	backendsMu.RLock()
+	defer backendsMu.RUnlock()
+	b, ok := backends[modelName]
+	if !ok {
+		return nil, nil, false
+	}
+	atomic.AddInt64(&b.inflight, 1)
+	metrics.InflightCalls.WithLabelValues(modelName).Inc()
+	return b, func() {
+		atomic.AddInt64(&b.inflight, -1)
+		metrics.InflightCalls.WithLabelValues(modelName).Dec()
+	}, true
+}
+
+// GetBackendStates 返回所有模型后端的连接状态
+func GetBackendStates() map[string]BackendState {
+	backendsMu.RLock()
+	defer backendsMu.RUnlock()
+	states := make(map[string]BackendState, len(backends))
+	for name, b := range backends {
+		states[name] = b.state()
+	}
+	return states
+}
+
+func lookupBackend(modelName string) (*backend, bool) {
+	backendsMu.RLock()
+	defer backendsMu.RUnlock()
+	b, ok := backends[modelName]
+	return b, ok
+}
+
+func GetConnection(modelName string) *websocket.Conn {
+	b, ok := lookupBackend(modelName)
+	if !ok {
+		return nil
+	}
+	return b.getConnection()
+}
@@ -0,0 +1,212 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"io"
+	"net/http"
+	"regexp"
+	"severless-task-scheduler/config"
+	"severless-task-scheduler/db/model"
+	"severless-task-scheduler/logging"
+	"severless-task-scheduler/tracing"
+	"sort"
+	"unicode/utf8"
+)
+
+var modelNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,64}$`)
+
+// ModelParameter 创建和更新模型的参数
+type ModelParameter struct {
+	Name        string            `json:"name"`
+	DisplayName string            `json:"display_name"`
+	Adapter     string            `json:"adapter"`
+	Api         string            `json:"api"`
+	Transport   string            `json:"transport"`
+	Retries     int               `json:"retries"`
+	Version     string            `json:"version"`
+	Cache       bool              `json:"cache"`
+	Enabled     *bool             `json:"enabled"`
+	Concurrency int               `json:"concurrency"`
+	Defaults    map[string]any    `json:"defaults"`
+	Headers     map[string]string `json:"headers"`
+	// Auth 中的密钥只接受 env: 或 file: 引用
+	Auth config.AuthConfig `json:"auth"`
+	TLS  config.TLSConfig  `json:"tls"`
+	Poll config.PollConfig `json:"poll"`
+}
+
+func CreateModel(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracing.StartHandler(r, "CreateModel")
+	defer span.End()
+
+	parameter, err := readModelParameter(r)
+	if err != nil {
+		responseError(w, err)
+		return
+	}
+	_, err = modelRepository.Get(ctx, parameter.Name)
+	if err == nil {
+		responseError(w, NewConflictError("model %s already exists", parameter.Name))
+		return
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		responseError(w, err)
+		return
+	}
+
+	record := parameter.record()
+	record.Enabled = true
+	if parameter.Enabled != nil {
+		record.Enabled = *parameter.Enabled
+	}
+	err = modelRepository.Create(ctx, record)
+	if err != nil {
+		logging.FromContext(ctx).WithError(err).Error("create model error")
+		responseError(w, err)
+		return
+	}
+	logging.FromContext(ctx).WithField(logging.FieldModel, record.Name).Info("model created")
+	if _, err = ReloadRegistry(ctx); err != nil {
+		logging.FromContext(ctx).WithError(err).Error("reload models error")
+	}
+	responseData(w, record)
+}
+
+// readModelParameter 读取并校验模型参数，模型来源不是数据库时拒绝修改
+func readModelParameter(r *http.Request) (*ModelParameter, error) {
+	if cfg.Registry.Source != config.RegistrySourceDatabase {
+		return nil, NewConflictError("models are read from %s, set registry.source to %s to manage them", cfg.Registry.Source, config.RegistrySourceDatabase)
+	}
+	bodyBytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	if len(bodyBytes) == 0 {
+		return nil, NewValidationError("parameter is required")
+	}
+	parameter := ModelParameter{}
+	err = json.Unmarshal(bodyBytes, &parameter)
+	if err != nil {
+		return nil, NewValidationError("invalid parameter", FieldError{Field: "", Message: fmt.Sprintf("invalid json: %v", err)})
+	}
+	if fieldErrors := parameter.validate(); len(fieldErrors) > 0 {
+		return nil, NewValidationError("invalid parameter", fieldErrors...)
+	}
+	return &parameter, nil
+}
+
+func (p *ModelParameter) validate() []FieldError {
+	fieldErrors := make([]FieldError, 0)
+	add := func(field string, format string, args ...any) {
+		fieldErrors = append(fieldErrors, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+	if !modelNamePattern.MatchString(p.Name) {
+		add("name", "must be 1 to 64 letters, digits, '_', '.' or '-'")
+	}
+	if utf8.RuneCountInString(p.DisplayName) > 128 {
+		add("display_name", "must be at most 128 characters")
+	}
+	endpoint := Model{Adapter: p.Adapter, Api: p.Api, Transport: p.Transport, Poll: p.Poll}.WithDefaults(p.Name)
+	if problem := endpoint.ValidateApi(); problem != "" {
+		add("api", "%s", problem)
+	}
+	if _, ok := adapters[p.Adapter]; ok {
+		if problem := endpoint.ValidateAdapter(); problem != "" {
+			add("transport", "%s", problem)
+		}
+	}
+	for _, problem := range endpoint.ValidatePoll() {
+		add("poll", "%s", problem)
+	}
+	if utf8.RuneCountInString(p.Version) > 64 {
+		add("version", "must be at most 64 characters")
+	}
+	if p.Retries < 0 {
+		add("retries", "must be greater than or equal to 0")
+	}
+	if p.Concurrency < 0 {
+		add("concurrency", "must be greater than or equal to 0")
+	}
+	for _, problem := range p.Auth.Validate() {
+		add("auth", "%s", problem)
+	}
+	if (p.TLS.CertFile == "") != (p.TLS.KeyFile == "") {
+		add("tls", "cert_file and key_file must be set together")
+	}
+
+	m := Model{Name: p.Name, Adapter: p.Adapter, Cache: p.Cache}
+	requestReflect, ok := requestType(m)
+	switch {
+	case ok:
+		// 默认值需要符合请求的参数 schema
+		schema := requestSchema(m, newPredictRequest(requestReflect))
+		for name, value := range p.Defaults {
+			property, ok := schema.Properties[name]
+			if !ok || name == "model" {
+				add("defaults."+name, "is not supported")
+				continue
+			}
+			if message := property.validate(value); message != "" {
+				add("defaults."+name, message)
+			}
+		}
+	case p.Adapter != "":
+		add("adapter", "must be one of %v", adapterNames())
+	default:
+		add("adapter", "is required for model %s", p.Name)
+	}
+	sort.Slice(
+		fieldErrors, func(i, j int) bool {
+			return fieldErrors[i].Field < fieldErrors[j].Field
+		},
+	)
+	return fieldErrors
+}
+
+func adapterNames() []string {
+	names := make([]string, 0, len(adapters))
+	for name := range adapters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// record 转换为 t_model 的记录，不包含 enabled
+func (p *ModelParameter) record() *model.RegisteredModel {
+	record := &model.RegisteredModel{
+		Name:        p.Name,
+		DisplayName: p.DisplayName,
+		Adapter:     p.Adapter,
+		API:         p.Api,
+		Transport:   p.Transport,
+		Retries:     int32(p.Retries),
+		Version:     p.Version,
+		Cache:       p.Cache,
+		Concurrency: int32(p.Concurrency),
+	}
+	if len(p.Defaults) > 0 {
+		defaults, _ := json.Marshal(p.Defaults)
+		record.DefaultParameters = StrPtr(string(defaults))
+	}
+	if len(p.Headers) > 0 {
+		headers, _ := json.Marshal(p.Headers)
+		record.Headers = StrPtr(string(headers))
+	}
+	if p.Auth != (config.AuthConfig{}) {
+		auth, _ := json.Marshal(p.Auth)
+		record.Auth = StrPtr(string(auth))
+	}
+	if p.TLS != (config.TLSConfig{}) {
+		tlsConfig, _ := json.Marshal(p.TLS)
+		record.TLS = StrPtr(string(tlsConfig))
+	}
+	if p.Poll.Async() {
+		poll, _ := json.Marshal(p.Poll)
+		record.Poll = StrPtr(string(poll))
+	}
+	return record
+}
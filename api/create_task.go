@@ -3,29 +3,15 @@ package api
 import (
 	"encoding/json"
 	"fmt"
-	"gorm.io/driver/mysql"
-	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
-	"io"
+	"github.com/sirupsen/logrus"
 	"net/http"
-	"os"
-	"severless-task-scheduler/db/api"
 	"severless-task-scheduler/db/model"
+	"severless-task-scheduler/logging"
+	"severless-task-scheduler/metrics"
+	"severless-task-scheduler/tracing"
+	"time"
 )
 
-var db *gorm.DB
-var query *api.Query
-
-func init() {
-	db, _ = gorm.Open(
-		mysql.Open(os.Getenv("DATABASE_DSN")),
-		&gorm.Config{
-			Logger: logger.Default.LogMode(logger.Info),
-		},
-	)
-	query = api.Use(db)
-}
-
 func responseData(w http.ResponseWriter, data any) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
@@ -52,12 +38,16 @@ func responseEmpty(w http.ResponseWriter) {
 }
 
 func responseError(w http.ResponseWriter, errMsg error) {
+	e := asError(errMsg)
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusInternalServerError)
+	w.WriteHeader(e.Status)
 
 	responseBody := map[string]any{
-		"code":    http.StatusInternalServerError,
-		"message": errMsg.Error(),
+		"code":    e.Code,
+		"message": e.Message,
+	}
+	if len(e.Fields) > 0 {
+		responseBody["errors"] = e.Fields
 	}
 	responseBytes, _ := json.Marshal(responseBody)
 	w.Write(responseBytes)
@@ -71,27 +61,126 @@ func BytesPtr(b []byte) *[]byte {
 	return &b
 }
 
+func IntPtr(i int) *int {
+	return &i
+}
+
 func CreateTask(w http.ResponseWriter, r *http.Request) {
-	bodyBytes, err := io.ReadAll(r.Body)
+	ctx, span := tracing.StartHandler(r, "CreateTask")
+	defer span.End()
+
+	idempotencyKey := r.Header.Get(IdempotencyKeyHeader)
+	if len(idempotencyKey) > MaxIdempotencyKeyLength {
+		message := fmt.Sprintf("must be at most %d characters", MaxIdempotencyKeyLength)
+		responseError(w, NewValidationError("invalid header", FieldError{Field: IdempotencyKeyHeader, Message: message}))
+		return
+	}
+	bodyBytes, inputs, err := readTaskRequest(w, r)
 	if err != nil {
 		responseError(w, err)
 		return
 	}
 	if len(bodyBytes) == 0 {
-		responseError(w, fmt.Errorf("parameter is required"))
+		responseError(w, NewValidationError("parameter is required"))
+		return
+	}
+	// 目前没有用户体系，所有任务的 UserID 都为 0
+	var userID int64
+	hash := requestHash(bodyBytes, inputs)
+	// 重复的请求直接返回原任务，即使参数在当前的模型配置下已经无效
+	if idempotencyKey != "" {
+		existing, ok, err := lookupIdempotentTask(ctx, userID, idempotencyKey)
+		if err != nil {
+			logging.FromContext(ctx).WithError(err).Error("get idempotency key error")
+			responseError(w, err)
+			return
+		}
+		if ok {
+			replayTask(ctx, w, existing, hash)
+			return
+		}
+	}
+	parameter, fieldErrors := validateTaskParameter(bodyBytes)
+	if len(fieldErrors) == 0 {
+		fieldErrors = validateTaskInputs(parameter, inputs)
+	}
+	if len(fieldErrors) > 0 {
+		responseError(w, NewValidationError("invalid parameter", fieldErrors...))
 		return
 	}
 
 	m := model.Task{
 		Parameter: string(bodyBytes),
-		UserID:    0,
+		Model:     parameter.Model,
+		UserID:    userID,
 		Status:    int32(Init),
 	}
+	if traceParent := tracing.TraceParent(ctx); traceParent != "" {
+		m.TraceParent = StrPtr(traceParent)
+	}
+	resultKey, reuse := prepareCache(bodyBytes, parameter.Model, inputs)
+	if resultKey != "" {
+		m.CacheKey = StrPtr(resultKey)
+	}
 
-	err = query.Task.Create(&m)
+	var key *model.IdempotencyKey
+	if idempotencyKey != "" {
+		key = &model.IdempotencyKey{
+			UserID:         userID,
+			IdempotencyKey: idempotencyKey,
+			RequestHash:    hash,
+			ExpiresAt:      time.Now().Add(cfg.Server.IdempotencyKeyTTL.Duration()),
+		}
+	}
+	// 相同的请求已经成功过时直接创建成功的任务并复制结果，不会被调度
+	cached := false
+	if reuse {
+		if sourceID := findCached(ctx, resultKey); sourceID != 0 {
+			cached, err = taskRepository.CreateCached(ctx, &m, inputs, key, sourceID)
+		}
+	}
+	if err == nil && !cached {
+		switch {
+		case key != nil:
+			err = taskRepository.CreateWithKey(ctx, &m, inputs, key)
+		case len(inputs) > 0:
+			err = taskRepository.CreateWithInputs(ctx, &m, inputs)
+		default:
+			err = taskRepository.Create(ctx, &m)
+		}
+	}
+	if err != nil && key != nil {
+		// 并发的相同请求已经创建了任务
+		if existing, ok, _ := lookupIdempotentTask(ctx, userID, idempotencyKey); ok {
+			replayTask(ctx, w, existing, hash)
+			return
+		}
+	}
 	if err != nil {
+		logging.FromContext(ctx).WithError(err).Error("create task error")
 		responseError(w, err)
 		return
 	}
+	logging.FromContext(ctx).WithFields(
+		logrus.Fields{
+			logging.FieldTaskID: m.ID,
+			logging.FieldModel:  m.Model,
+			logging.FieldUserID: m.UserID,
+		},
+	).Info("task created")
+	if cached {
+		metrics.CacheHits.WithLabelValues(m.Model, metrics.StageCreate).Inc()
+		logging.FromContext(ctx).WithField("cached_from", *m.CachedFrom).Info("task result reused")
+		// 返回包含复制的图片的任务
+		task, err := taskRepository.Get(ctx, m.ID)
+		if err != nil {
+			logging.FromContext(ctx).WithError(err).Error("get task error")
+			responseError(w, err)
+			return
+		}
+		responseData(w, task)
+		return
+	}
+	NotifySchedule()
 	responseData(w, m)
 }
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"severless-task-scheduler/db/model"
+	"testing"
+)
+
+func TestCreateTask(t *testing.T) {
+	m, _ := a1111Backend(t, nil)
+	setupTest(t, map[string]Model{"sd": m})
+
+	recorder, response := createTestTask(t, `{"model":"sd","prompt":"cat"}`)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("got status %d: %s", recorder.Code, response.Message)
+	}
+	task := model.Task{}
+	if err := json.Unmarshal(response.Data, &task); err != nil {
+		t.Fatalf("decode task: %v", err)
+	}
+	if task.ID == 0 || task.Status != int32(Init) || task.Model != "sd" {
+		t.Errorf("got id %d status %d model %q, want a new init task of sd", task.ID, task.Status, task.Model)
+	}
+}
+
+func TestCreateTaskValidation(t *testing.T) {
+	m, _ := a1111Backend(t, nil)
+	setupTest(t, map[string]Model{"sd": m})
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"empty", ``},
+		{"invalid json", `{`},
+		{"unknown model", `{"model":"missing","prompt":"cat"}`},
+		{"missing prompt", `{"model":"sd"}`},
+		{"out of range", `{"model":"sd","prompt":"cat","width":4096}`},
+	}
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				recorder, response := createTestTask(t, test.body)
+				if recorder.Code != http.StatusBadRequest || response.Code != CodeValidation {
+					t.Errorf("got status %d code %v, want 400 %s", recorder.Code, response.Code, CodeValidation)
+				}
+			},
+		)
+	}
+}
+
+func TestCreateTaskIdempotencyKey(t *testing.T) {
+	m, _ := a1111Backend(t, nil)
+	setupTest(t, map[string]Model{"sd": m})
+
+	create := func(body string) (*httptest.ResponseRecorder, testResponse) {
+		r := httptest.NewRequest(http.MethodPost, "/api/create_task", bytes.NewBufferString(body))
+		r.Header.Set(IdempotencyKeyHeader, "key")
+		return serve(t, CreateTask, r)
+	}
+	_, first := create(`{"model":"sd","prompt":"cat"}`)
+	recorder, replayed := create(`{"model":"sd","prompt":"cat"}`)
+	if recorder.Header().Get(IdempotentReplayedHeader) != "true" || string(replayed.Data) != string(first.Data) {
+		t.Errorf("replay: got header %q and a different task, want the original task", recorder.Header().Get(IdempotentReplayedHeader))
+	}
+	recorder, _ = create(`{"model":"sd","prompt":"dog"}`)
+	if recorder.Code != http.StatusConflict {
+		t.Errorf("different body: got status %d, want 409", recorder.Code)
+	}
+}
+
+func TestCreateTaskCached(t *testing.T) {
+	m, calls := a1111Backend(t, nil)
+	m.Cache = true
+	setupTest(t, map[string]Model{"sd": m})
+
+	body := `{"model":"sd","prompt":"cat","rand_seed":42}`
+	createTestTask(t, body)
+	scheduleAndDrain(t)
+
+	recorder, response := createTestTask(t, body)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("got status %d: %s", recorder.Code, response.Message)
+	}
+	task := model.Task{}
+	if err := json.Unmarshal(response.Data, &task); err != nil {
+		t.Fatalf("decode task: %v", err)
+	}
+	if task.Status != int32(Success) || task.CachedFrom == nil || *task.CachedFrom != 1 {
+		t.Errorf("got status %d cached_from %v, want success from task 1", task.Status, task.CachedFrom)
+	}
+	if task.Image1 == nil {
+		t.Error("got no image1, want the image of task 1")
+	}
+	// 复用结果的任务创建时已经成功，不会被调度
+	scheduleAndDrain(t)
+	if *calls != 1 {
+		t.Errorf("got %d calls, want the cached task not to be dispatched", *calls)
+	}
+}
@@ -0,0 +1,45 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"gorm.io/gorm"
+	"net/http"
+	"severless-task-scheduler/config"
+	"severless-task-scheduler/logging"
+	"severless-task-scheduler/tracing"
+)
+
+// DisableModel 停用模型，已派发的任务执行完后断开连接，排队中的任务在模型重新启用前不会被调度
+func DisableModel(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracing.StartHandler(r, "DisableModel")
+	defer span.End()
+
+	if cfg.Registry.Source != config.RegistrySourceDatabase {
+		responseError(w, NewConflictError("models are read from %s, set registry.source to %s to manage them", cfg.Registry.Source, config.RegistrySourceDatabase))
+		return
+	}
+	parameter := struct {
+		Name string `json:"name"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&parameter); err != nil || parameter.Name == "" {
+		responseError(w, NewValidationError("name is required"))
+		return
+	}
+
+	err := modelRepository.SetEnabled(ctx, parameter.Name, false)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		responseError(w, NewNotFoundError("model %s not found", parameter.Name))
+		return
+	}
+	if err != nil {
+		logging.FromContext(ctx).WithError(err).Error("disable model error")
+		responseError(w, err)
+		return
+	}
+	logging.FromContext(ctx).WithField(logging.FieldModel, parameter.Name).Info("model disabled")
+	if _, err = ReloadRegistry(ctx); err != nil {
+		logging.FromContext(ctx).WithError(err).Error("reload models error")
+	}
+	responseEmpty(w)
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"net/http"
+	"severless-task-scheduler/db/repository"
+)
+
+const (
+	CodeValidation   = "validation_failed"
+	CodeNotFound     = "not_found"
+	CodeConflict     = "conflict"
+	CodeUnauthorized = "unauthorized"
+	CodeUnavailable  = "unavailable"
+	CodeTooLarge     = "payload_too_large"
+	CodeInternal     = "internal_error"
+)
+
+// Error 是带有 HTTP 状态码和稳定错误码的业务错误
+type Error struct {
+	Status  int
+	Code    string
+	Message string
+	Fields  []FieldError
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func NewValidationError(message string, fields ...FieldError) *Error {
+	return &Error{Status: http.StatusBadRequest, Code: CodeValidation, Message: message, Fields: fields}
+}
+
+func NewNotFoundError(format string, args ...any) *Error {
+	return &Error{Status: http.StatusNotFound, Code: CodeNotFound, Message: fmt.Sprintf(format, args...)}
+}
+
+func NewConflictError(format string, args ...any) *Error {
+	return &Error{Status: http.StatusConflict, Code: CodeConflict, Message: fmt.Sprintf(format, args...)}
+}
+
+func NewUnauthorizedError(format string, args ...any) *Error {
+	return &Error{Status: http.StatusUnauthorized, Code: CodeUnauthorized, Message: fmt.Sprintf(format, args...)}
+}
+
+func NewTooLargeError(format string, args ...any) *Error {
+	return &Error{Status: http.StatusRequestEntityTooLarge, Code: CodeTooLarge, Message: fmt.Sprintf(format, args...)}
+}
+
+func NewUnavailableError(format string, args ...any) *Error {
+	return &Error{Status: http.StatusServiceUnavailable, Code: CodeUnavailable, Message: fmt.Sprintf(format, args...)}
+}
+
+// asError 把任意错误转换为 *Error，无法识别的错误视为内部错误
+func asError(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return NewTooLargeError("request body exceeds %d bytes", tooLarge.Limit)
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &Error{Status: http.StatusNotFound, Code: CodeNotFound, Message: err.Error()}
+	}
+	if errors.Is(err, repository.ErrDuplicateKey) {
+		return NewConflictError("%s", err.Error())
+	}
+	return &Error{Status: http.StatusInternalServerError, Code: CodeInternal, Message: err.Error()}
+}
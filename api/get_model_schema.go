@@ -0,0 +1,32 @@
+package api
+
+import (
+	"net/http"
+	"severless-task-scheduler/tracing"
+)
+
+func GetModelSchema(w http.ResponseWriter, r *http.Request) {
+	_, span := tracing.StartHandler(r, "GetModelSchema")
+	defer span.End()
+
+	modelName := r.URL.Query().Get("model")
+	if modelName != "" {
+		schema, ok := lookupModelSchema(modelName)
+		if !ok {
+			responseError(w, NewNotFoundError("model %s not found", modelName))
+			return
+		}
+		responseData(w, schema)
+		return
+	}
+
+	schemas := make(map[string]*Schema)
+	for name := range Models() {
+		schema, ok := lookupModelSchema(name)
+		if !ok {
+			continue
+		}
+		schemas[name] = schema
+	}
+	responseData(w, schemas)
+}
@@ -1,37 +1,62 @@
 package api
 
 import (
-	"fmt"
+	"errors"
+	"gorm.io/gorm"
 	"net/http"
+	"severless-task-scheduler/db/model"
+	"severless-task-scheduler/logging"
+	"severless-task-scheduler/tracing"
 	"strconv"
 )
 
+// TaskDetail 任务及其输出
+type TaskDetail struct {
+	*model.Task
+	Outputs []OutputInfo `json:"outputs"`
+}
+
 func GetTask(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracing.StartHandler(r, "GetTask")
+	defer span.End()
+
 	request := r.URL.Query()
 	params, exist := request["id"]
 	if !exist {
-		responseError(w, fmt.Errorf("id is required"))
+		responseError(w, NewValidationError("id is required"))
 		return
 	}
 	if len(params) == 0 {
-		responseError(w, fmt.Errorf("id is required"))
+		responseError(w, NewValidationError("id is required"))
 		return
 	}
 	if params[0] == "" {
-		responseError(w, fmt.Errorf("id is required"))
+		responseError(w, NewValidationError("id is required"))
 		return
 	}
 	id, err := strconv.ParseInt(params[0], 10, 64)
 	if err != nil {
+		responseError(w, NewValidationError("id must be an integer", FieldError{Field: "id", Message: err.Error()}))
+		return
+	}
+
+	first, err := taskRepository.Get(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		responseError(w, NewNotFoundError("task %d not found", id))
+		return
+	}
+	if err != nil {
+		logging.FromContext(ctx).WithField(logging.FieldTaskID, id).WithError(err).Error("get task error")
 		responseError(w, err)
 		return
 	}
 
-	first, err := query.Task.Where(query.Task.ID.Eq(id)).First()
+	outputs, err := taskRepository.ListOutputs(ctx, id)
 	if err != nil {
+		logging.FromContext(ctx).WithField(logging.FieldTaskID, id).WithError(err).Error("list task outputs error")
 		responseError(w, err)
 		return
 	}
 
-	responseData(w, first)
+	responseData(w, TaskDetail{Task: first, Outputs: outputInfos(outputs)})
 }
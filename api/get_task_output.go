@@ -0,0 +1,93 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"net/http"
+	"net/url"
+	"severless-task-scheduler/logging"
+	"severless-task-scheduler/media"
+	"severless-task-scheduler/tracing"
+	"strconv"
+)
+
+// GetTaskOutput 返回任务第 index 个输出的内容，保存在后端的输出重定向到其地址
+func GetTaskOutput(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracing.StartHandler(r, "GetTaskOutput")
+	defer span.End()
+
+	query := r.URL.Query()
+	if query.Get("task_id") == "" {
+		responseError(w, NewValidationError("task_id is required"))
+		return
+	}
+	taskID, err := strconv.ParseInt(query.Get("task_id"), 10, 64)
+	if err != nil {
+		responseError(w, NewValidationError("task_id must be an integer", FieldError{Field: "task_id", Message: err.Error()}))
+		return
+	}
+	index, err := strconv.ParseInt(query.Get("index"), 10, 32)
+	if err != nil || index < 0 {
+		responseError(w, NewValidationError("index must be a non-negative integer", FieldError{Field: "index", Message: "is invalid"}))
+		return
+	}
+
+	output, err := taskRepository.GetOutput(ctx, taskID, int32(index))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		responseError(w, NewNotFoundError("output %d of task %d not found", index, taskID))
+		return
+	}
+	if err != nil {
+		logging.FromContext(ctx).WithField(logging.FieldTaskID, taskID).WithError(err).Error("get task output error")
+		responseError(w, err)
+		return
+	}
+
+	var data []byte
+	slot, isImage := imageSlot(output.StorageRef)
+	switch {
+	case output.StorageRef == StorageRefInline:
+		if output.Data == nil {
+			responseError(w, NewNotFoundError("output %d of task %d has been purged", index, taskID))
+			return
+		}
+		data = *output.Data
+	case isImage:
+		task, err := taskRepository.Get(ctx, taskID)
+		if err != nil {
+			logging.FromContext(ctx).WithField(logging.FieldTaskID, taskID).WithError(err).Error("get task error")
+			responseError(w, err)
+			return
+		}
+		images := []*[]byte{task.Image1, task.Image2, task.Image3, task.Image4}
+		if images[slot-1] == nil {
+			responseError(w, NewNotFoundError("output %d of task %d has been purged", index, taskID))
+			return
+		}
+		data, err = media.DecodeImage(*images[slot-1])
+		if err != nil {
+			responseError(w, fmt.Errorf("decode image error: %v", err))
+			return
+		}
+	case isRemoteRef(output.StorageRef):
+		http.Redirect(w, r, output.StorageRef, http.StatusFound)
+		return
+	default:
+		// 超出范围的图片字段或无法识别的位置，不能重定向到任意地址
+		logging.FromContext(ctx).WithField(logging.FieldTaskID, taskID).Errorf("invalid output storage ref %q", output.StorageRef)
+		responseError(w, fmt.Errorf("output %d of task %d has an invalid storage ref", index, taskID))
+		return
+	}
+
+	w.Header().Set("Content-Type", output.MimeType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// isRemoteRef 保存在后端的输出的位置是否为 http(s) 的绝对地址
+func isRemoteRef(storageRef string) bool {
+	u, err := url.Parse(storageRef)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"severless-task-scheduler/db/model"
+	"testing"
+)
+
+func TestGetTaskOutputStorageRef(t *testing.T) {
+	r := setupTest(t, map[string]Model{})
+	ctx := context.Background()
+	task := &model.Task{Parameter: "{}", Model: "sd"}
+	if err := r.Create(ctx, task); err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	outputs := []*model.TaskOutput{
+		{Seq: 0, Kind: OutputImage, MimeType: "image/png", StorageRef: "https://cdn.example.com/0.png"},
+		{Seq: 1, Kind: OutputImage, MimeType: "image/png", StorageRef: "t_task.image9"},
+		{Seq: 2, Kind: OutputImage, MimeType: "image/png", StorageRef: "cdn.example.com/2.png"},
+	}
+	if err := r.SaveResult(ctx, task.ID, nil, outputs); err != nil {
+		t.Fatalf("save result: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		index  string
+		status int
+	}{
+		{"remote url", "0", http.StatusFound},
+		{"image slot out of range", "1", http.StatusInternalServerError},
+		{"unknown ref", "2", http.StatusInternalServerError},
+	}
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				query := url.Values{"task_id": {jsonNumber(task.ID)}, "index": {test.index}}
+				recorder := httptest.NewRecorder()
+				GetTaskOutput(recorder, httptest.NewRequest(http.MethodGet, "/api/get_task_output?"+query.Encode(), nil))
+				if recorder.Code != test.status {
+					t.Errorf("got status %d location %q, want %d", recorder.Code, recorder.Header().Get("Location"), test.status)
+				}
+			},
+		)
+	}
+}
@@ -0,0 +1,39 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGetTask(t *testing.T) {
+	m, _ := a1111Backend(t, nil)
+	setupTest(t, map[string]Model{"sd": m})
+
+	tests := []struct {
+		name   string
+		query  string
+		status int
+		code   string
+	}{
+		{"missing id", "", http.StatusBadRequest, CodeValidation},
+		{"invalid id", "?id=abc", http.StatusBadRequest, CodeValidation},
+		{"not found", "?id=100", http.StatusNotFound, CodeNotFound},
+	}
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				recorder, response := serve(t, GetTask, httptest.NewRequest(http.MethodGet, "/api/get_task"+test.query, nil))
+				if recorder.Code != test.status || response.Code != test.code {
+					t.Errorf("got status %d code %v, want %d %s", recorder.Code, response.Code, test.status, test.code)
+				}
+			},
+		)
+	}
+
+	createTestTask(t, `{"model":"sd","prompt":"cat"}`)
+	detail := getTestTask(t, 1)
+	if detail.ID != 1 || detail.Status != int32(Init) || len(detail.Outputs) != 0 {
+		t.Errorf("got id %d status %d with %d outputs, want the new task without outputs", detail.ID, detail.Status, len(detail.Outputs))
+	}
+}
@@ -0,0 +1,10 @@
+package api
+
+import (
+	"net/http"
+)
+
+// Healthz 存活检查，进程能处理请求即返回成功
+func Healthz(w http.ResponseWriter, r *http.Request) {
+	responseData(w, map[string]string{"status": "ok"})
+}
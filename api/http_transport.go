@@ -0,0 +1,209 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"io"
+	"net/http"
+	"net/url"
+	"severless-task-scheduler/metrics"
+	"severless-task-scheduler/tracing"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// httpTransport 每次调用发送一个 POST 请求，网络错误、429 和 5xx 按指数退避重试
+// 配置了 Poll.StatusApi 的模型通过 Submit 和 Poll 异步获取结果
+type httpTransport struct {
+	model  Model
+	client *http.Client
+	// err TLS 配置无效时所有调用都返回该错误
+	err error
+	// failed 最近一次请求是否因网络错误失败
+	failed atomic.Bool
+}
+
+func newHTTPTransport(m Model) *httpTransport {
+	tlsConfig, err := backendTLS(m)
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	return &httpTransport{
+		model:  m,
+		client: &http.Client{Transport: transport, Timeout: m.ReadTimeout.Duration()},
+		err:    err,
+	}
+}
+
+func (t *httpTransport) Call(ctx context.Context, request PredictRequest) ([]byte, error) {
+	return t.post(ctx, request, t.model.Retries)
+}
+
+// post 向 Api 或请求指定的接口发送请求
+func (t *httpTransport) post(ctx context.Context, request PredictRequest, retries int) ([]byte, error) {
+	api := t.model.Api
+	if endpoint, ok := request.(EndpointRequest); ok {
+		api = endpoint.Endpoint(api)
+	}
+	return t.send(ctx, http.MethodPost, api, request.Json(), retries)
+}
+
+// send 发送请求，可重试的错误最多重试 retries 次
+func (t *httpTransport) send(ctx context.Context, method string, url string, payload []byte, retries int) ([]byte, error) {
+	if t.err != nil {
+		return nil, transportError(metrics.ReasonNoConnection, "create transport error, model: %s: %v", t.model.Name, t.err)
+	}
+	backoff := t.model.RetryBackoff.Duration()
+	var err error
+	for attempt := 0; attempt <= retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, transportError(metrics.ReasonWrite, "request canceled: %v", ctx.Err())
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+		var body []byte
+		var retryable bool
+		body, retryable, err = t.attempt(ctx, method, url, payload, attempt)
+		if err == nil {
+			return body, nil
+		}
+		if !retryable {
+			return nil, err
+		}
+	}
+	return nil, err
+}
+
+// attempt 发送一次请求，返回的错误是否可以重试
+func (t *httpTransport) attempt(ctx context.Context, method string, url string, payload []byte, attempt int) ([]byte, bool, error) {
+	ctx, span := tracing.Tracer.Start(ctx, "call.http")
+	span.SetAttributes(attribute.String("http.method", method), attribute.Int("attempt", attempt))
+	body, retryable, err := t.do(ctx, method, url, payload)
+	tracing.End(span, err)
+	return body, retryable, err
+}
+
+func (t *httpTransport) do(ctx context.Context, method string, url string, payload []byte) ([]byte, bool, error) {
+	header, err := backendHeader(t.model)
+	if err != nil {
+		return nil, false, transportError(metrics.ReasonNoConnection, "build request header error: %v", err)
+	}
+	var reader io.Reader
+	if payload != nil {
+		reader = bytes.NewReader(payload)
+	}
+	request, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return nil, false, transportError(metrics.ReasonWrite, "build request error: %v", err)
+	}
+	request.Header = header
+	// 把 call.http 的链路传给后端
+	propagation.TraceContext{}.Inject(ctx, propagation.HeaderCarrier(request.Header))
+	if payload != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+
+	response, err := t.client.Do(request)
+	if err != nil {
+		t.failed.Store(true)
+		return nil, !errors.Is(err, context.Canceled), transportError(metrics.ReasonWrite, "%s request error: %v", strings.ToLower(method), err)
+	}
+	defer response.Body.Close()
+	t.failed.Store(false)
+
+	body, err := io.ReadAll(io.LimitReader(response.Body, t.model.MaxReadSize+1))
+	if err != nil {
+		return nil, true, transportError(metrics.ReasonRead, "read response error: %v", err)
+	}
+	if int64(len(body)) > t.model.MaxReadSize {
+		return nil, false, transportError(metrics.ReasonRead, "read response error: response exceeds %d bytes", t.model.MaxReadSize)
+	}
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		retryable := response.StatusCode == http.StatusTooManyRequests || response.StatusCode >= 500
+		statusErr := transportError(metrics.ReasonStatus, "unexpected status %d: %s", response.StatusCode, truncate(body, 200))
+		statusErr.Status = response.StatusCode
+		return nil, retryable, statusErr
+	}
+	return body, false, nil
+}
+
+// Submit 向 Api 提交请求，返回后端的任务 ID。后端可能已经创建了任务但响应丢失，重试会重复提交，所以不重试
+func (t *httpTransport) Submit(ctx context.Context, request PredictRequest) (string, error) {
+	body, err := t.post(ctx, request, 0)
+	if err != nil {
+		return "", err
+	}
+	response := struct {
+		ID json.RawMessage `json:"id"`
+	}{}
+	if err = json.Unmarshal(body, &response); err != nil {
+		return "", transportError(metrics.ReasonDecode, "decode submit response error: %v", err)
+	}
+	jobID := rawString(response.ID)
+	if jobID == "" {
+		return "", transportError(metrics.ReasonDecode, "decode submit response error: no job id in %s", truncate(body, 200))
+	}
+	return jobID, nil
+}
+
+// Poll 从 Poll.StatusApi 查询任务状态
+func (t *httpTransport) Poll(ctx context.Context, jobID string) (*JobStatus, error) {
+	statusApi := strings.ReplaceAll(t.model.Poll.StatusApi, "{id}", url.PathEscape(jobID))
+	body, err := t.send(ctx, http.MethodGet, statusApi, nil, t.model.Retries)
+	if err != nil {
+		return nil, err
+	}
+	response := struct {
+		Status string          `json:"status"`
+		Output json.RawMessage `json:"output"`
+		Error  json.RawMessage `json:"error"`
+	}{}
+	if err = json.Unmarshal(body, &response); err != nil {
+		return nil, transportError(metrics.ReasonDecode, "decode status response error: %v", err)
+	}
+	status := &JobStatus{}
+	switch strings.ToLower(response.Status) {
+	case "succeeded", "success", "completed":
+		status.Done = true
+		status.Output = response.Output
+	case "failed", "error", "canceled", "cancelled":
+		status.Done = true
+		status.Error = rawString(response.Error)
+		if status.Error == "" {
+			status.Error = response.Status
+		}
+	}
+	return status, nil
+}
+
+func (t *httpTransport) Connected() bool {
+	return t.err == nil && !t.failed.Load()
+}
+
+// rawString 取出 JSON 字符串的值，数字和对象保留原文，null 返回空字符串
+func rawString(raw json.RawMessage) string {
+	value := ""
+	if err := json.Unmarshal(raw, &value); err == nil {
+		return value
+	}
+	if string(raw) == "null" {
+		return ""
+	}
+	return string(raw)
+}
+
+// truncate 截取响应的开头用于错误信息
+func truncate(body []byte, size int) string {
+	if len(body) <= size {
+		return string(body)
+	}
+	return fmt.Sprintf("%s...", body[:size])
+}
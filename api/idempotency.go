@@ -0,0 +1,74 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"gorm.io/gorm"
+	"net/http"
+	"severless-task-scheduler/db/model"
+	"severless-task-scheduler/logging"
+	"sort"
+)
+
+const (
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader 响应是重复请求返回的原任务时为 true
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	MaxIdempotencyKeyLength = 255
+)
+
+// requestHash 请求内容的摘要，用于判断同一个幂等键的请求是否相同，输入文件按名称排序
+func requestHash(body []byte, inputs []*model.TaskInput) string {
+	sorted := make([]*model.TaskInput, len(inputs))
+	copy(sorted, inputs)
+	sort.Slice(
+		sorted, func(i, j int) bool {
+			return sorted[i].Name < sorted[j].Name
+		},
+	)
+	hash := sha256.New()
+	hash.Write(body)
+	for _, input := range sorted {
+		data := sha256.Sum256(input.Data)
+		hash.Write([]byte{0})
+		hash.Write([]byte(input.Name + "\x00" + input.ContentType + "\x00"))
+		hash.Write(data[:])
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// lookupIdempotentTask 查找幂等键对应的原任务，没有未过期的幂等键时返回 false
+func lookupIdempotentTask(ctx context.Context, userID int64, key string) (*model.IdempotencyKey, bool, error) {
+	existing, err := taskRepository.GetKey(ctx, userID, key)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return existing, true, nil
+}
+
+// replayTask 返回幂等键对应的原任务，请求内容与原请求不同时返回 409
+func replayTask(ctx context.Context, w http.ResponseWriter, key *model.IdempotencyKey, hash string) {
+	if key.RequestHash != hash {
+		responseError(w, NewConflictError("%s %q is already used by a different request", IdempotencyKeyHeader, key.IdempotencyKey))
+		return
+	}
+	task, err := taskRepository.Get(ctx, key.TaskID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		responseError(w, NewNotFoundError("task %d of %s %q not found", key.TaskID, IdempotencyKeyHeader, key.IdempotencyKey))
+		return
+	}
+	if err != nil {
+		logging.FromContext(ctx).WithField(logging.FieldTaskID, key.TaskID).WithError(err).Error("get task error")
+		responseError(w, err)
+		return
+	}
+	logging.FromContext(ctx).WithField(logging.FieldTaskID, task.ID).Info("task creation replayed")
+	w.Header().Set(IdempotentReplayedHeader, "true")
+	responseData(w, task)
+}
@@ -0,0 +1,163 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"severless-task-scheduler/db/model"
+	"severless-task-scheduler/logging"
+	"severless-task-scheduler/metrics"
+	"severless-task-scheduler/tracing"
+	"sync"
+	"time"
+)
+
+const (
+	// JobPollLease 认领后在该时间内不会被其他调度者重复轮询
+	JobPollLease = 5 * time.Minute
+	// JobPollTimeout 单次查询异步任务状态的超时时间
+	JobPollTimeout = 30 * time.Second
+)
+
+// submit 向异步后端提交请求并记录后端的任务 ID，结果由 pollJobs 获取
+func submit(ctx context.Context, m Model, b *backend, task *model.Task, request PredictRequest) Status {
+	jobs, ok := b.transport.(JobTransport)
+	if !ok {
+		// 更新任务状态为失败
+		failTask(ctx, task, m.Name, metrics.ReasonNoConnection, fmt.Sprintf("model %s does not support async jobs", m.Name))
+		return Fail
+	}
+	_, phase := tracing.Tracer.Start(ctx, "call.submit")
+	jobID, err := jobs.Submit(ctx, request)
+	tracing.End(phase, err)
+	if err != nil {
+		// 更新任务状态为失败
+		failTask(ctx, task, m.Name, transportReason(err), err.Error())
+		return Fail
+	}
+	err = taskRepository.SubmitJob(ctx, task.ID, jobID, time.Now().Add(m.Poll.Interval.Duration()))
+	if err != nil {
+		logging.FromContext(ctx).WithError(err).Error("update task error")
+		metrics.TaskFailures.WithLabelValues(m.Name, metrics.ReasonPersist).Inc()
+		return Fail
+	}
+	logging.FromContext(ctx).WithField("job_id", jobID).Info("job submitted")
+	return Running
+}
+
+// pollJobs 认领最多 limit 个到期的异步任务并查询结果，全部查询结束后返回。
+// ctx 有截止时间时（如 schedule_task 请求）查询最多使用剩余时间的一半，为之后的认领和派发留出时间
+func pollJobs(ctx context.Context, limit int) {
+	timeout := JobPollTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		if budget := time.Until(deadline) / 2; budget < timeout {
+			timeout = budget
+		}
+	}
+	if timeout <= 0 {
+		return
+	}
+	tasks, err := taskRepository.ClaimJobs(ctx, limit, JobPollLease)
+	if err != nil {
+		logging.FromContext(ctx).WithError(err).Error("claim job error")
+	}
+	wg := sync.WaitGroup{}
+	for _, task := range tasks {
+		wg.Add(1)
+		go func(task *model.Task) {
+			defer wg.Done()
+			pollJob(ctx, task, timeout)
+		}(task)
+	}
+	wg.Wait()
+}
+
+// pollJob 查询一个异步任务，查询最多等待 timeout，未完成时推迟到下一次轮询
+func pollJob(ctx context.Context, task *model.Task, timeout time.Duration) {
+	ctx = logging.WithFields(
+		ctx, logrus.Fields{
+			logging.FieldTaskID: task.ID,
+			logging.FieldModel:  task.Model,
+			logging.FieldUserID: task.UserID,
+			"job_id":            *task.ExternalJobID,
+		},
+	)
+	ctx, span := tracing.Tracer.Start(
+		ctx, "poll",
+		trace.WithAttributes(attribute.Int64("task.id", task.ID), attribute.String("model", task.Model)),
+	)
+	defer span.End()
+
+	m, ok := lookupModel(task.Model)
+	if !ok {
+		// 更新任务状态为失败
+		failTask(ctx, task, task.Model, metrics.ReasonModelNotFound, fmt.Sprintf("model %s not found", task.Model))
+		return
+	}
+	var jobs JobTransport
+	if b, ok := lookupBackend(m.Name); ok {
+		jobs, _ = b.transport.(JobTransport)
+	}
+	if jobs == nil || !m.Poll.Async() {
+		// 更新任务状态为失败
+		failTask(ctx, task, m.Name, metrics.ReasonNoConnection, fmt.Sprintf("model %s does not support async jobs", m.Name))
+		return
+	}
+	submittedAt := task.CreatedAt
+	if task.JobSubmittedAt != nil {
+		submittedAt = *task.JobSubmittedAt
+	}
+	if time.Since(submittedAt) > m.Poll.Timeout.Duration() {
+		// 更新任务状态为失败
+		failTask(ctx, task, m.Name, metrics.ReasonTimeout, fmt.Sprintf("job not finished in %s", m.Poll.Timeout.Duration()))
+		metrics.GenerationTime.WithLabelValues(m.Name, Fail.String()).Observe(time.Since(submittedAt).Seconds())
+		return
+	}
+
+	pollCtx, cancel := context.WithTimeout(ctx, timeout)
+	status, err := jobs.Poll(pollCtx, *task.ExternalJobID)
+	cancel()
+	if err != nil && terminalPollError(err) {
+		// 更新任务状态为失败
+		failTask(ctx, task, m.Name, metrics.ReasonStatus, err.Error())
+		metrics.GenerationTime.WithLabelValues(m.Name, Fail.String()).Observe(time.Since(submittedAt).Seconds())
+		return
+	}
+	if err != nil {
+		// 网络错误、429 和 5xx 等到下一次轮询再查询
+		logging.FromContext(ctx).WithError(err).Warn("poll job error")
+	}
+	if err != nil || !status.Done {
+		err = taskRepository.DeferJob(ctx, task.ID, time.Now().Add(m.Poll.Interval.Duration()))
+		if err != nil {
+			logging.FromContext(ctx).WithError(err).Error("update task error")
+		}
+		return
+	}
+
+	result := Fail
+	if status.Error != "" {
+		// 更新任务状态为失败
+		failTask(ctx, task, m.Name, metrics.ReasonJobFailed, fmt.Sprintf("job failed: %s", status.Error))
+	} else if request, _, reason, err := buildRequest(ctx, m, task); err != nil {
+		// 更新任务状态为失败
+		failTask(ctx, task, m.Name, reason, err.Error())
+	} else {
+		// 与派发时一样解析请求，解码输出时可能用到请求中的参数，如语音的格式
+		result = saveResult(ctx, m, task, request, status.Output)
+	}
+	metrics.GenerationTime.WithLabelValues(m.Name, result.String()).Observe(time.Since(submittedAt).Seconds())
+}
+
+// terminalPollError 状态接口返回 4xx（如任务不存在的 404）时再查询也不会有结果，429 为限流，仍等到下一次轮询
+func terminalPollError(err error) bool {
+	var transportErr *TransportError
+	if !errors.As(err, &transportErr) {
+		return false
+	}
+	return transportErr.Status >= 400 && transportErr.Status < 500 && transportErr.Status != http.StatusTooManyRequests
+}
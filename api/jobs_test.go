@@ -0,0 +1,146 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"severless-task-scheduler/config"
+	"strings"
+	"testing"
+	"time"
+)
+
+// jobBackend 模拟异步后端，提交返回 submitStatus，状态接口返回 pollStatus
+func jobBackend(t *testing.T, submitStatus *int, pollStatus *int) (Model, *int) {
+	t.Helper()
+	m, calls := a1111Backend(
+		t, func(w http.ResponseWriter, r *http.Request) {
+			status := *submitStatus
+			if r.Method == http.MethodGet {
+				status = *pollStatus
+			}
+			if status != http.StatusOK {
+				http.Error(w, http.StatusText(status), status)
+				return
+			}
+			if r.Method == http.MethodGet {
+				_, _ = w.Write([]byte(`{"status":"running"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"id":"job"}`))
+		},
+	)
+	m.Retries = 2
+	m.Poll = config.PollConfig{
+		StatusApi: strings.TrimSuffix(m.Api, "/sdapi/v1/txt2img") + "/status/{id}",
+		Interval:  config.Duration(time.Millisecond),
+		Timeout:   config.Duration(time.Hour),
+	}
+	return m, calls
+}
+
+func TestSubmitNotRetried(t *testing.T) {
+	submitStatus, pollStatus := http.StatusServiceUnavailable, http.StatusOK
+	m, calls := jobBackend(t, &submitStatus, &pollStatus)
+	setupTest(t, map[string]Model{"sd": m})
+
+	createTestTask(t, `{"model":"sd","prompt":"cat"}`)
+	scheduleAndDrain(t)
+
+	if detail := getTestTask(t, 1); detail.Status != int32(Fail) {
+		t.Errorf("got status %d, want failed", detail.Status)
+	}
+	if *calls != 1 {
+		t.Errorf("got %d calls, want submit not to be retried", *calls)
+	}
+}
+
+func TestPollJobStatusError(t *testing.T) {
+	submitStatus, pollStatus := http.StatusOK, http.StatusServiceUnavailable
+	m, _ := jobBackend(t, &submitStatus, &pollStatus)
+	setupTest(t, map[string]Model{"sd": m})
+
+	createTestTask(t, `{"model":"sd","prompt":"cat"}`)
+	scheduleAndDrain(t)
+	poll := func() int32 {
+		time.Sleep(10 * time.Millisecond)
+		pollJobs(context.Background(), 5)
+		return getTestTask(t, 1).Status
+	}
+
+	if status := poll(); status != int32(Running) {
+		t.Errorf("5xx: got status %d, want the job to be polled again", status)
+	}
+	pollStatus = http.StatusNotFound
+	if status := poll(); status != int32(Fail) {
+		t.Errorf("404: got status %d, want failed", status)
+	}
+}
+
+func TestPollJobDecodesWithTaskParameter(t *testing.T) {
+	m, _ := a1111Backend(
+		t, func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet {
+				_, _ = w.Write([]byte(`{"status":"succeeded","output":"audio"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"id":"job"}`))
+		},
+	)
+	m.Adapter = config.AdapterSpeech
+	m.Poll = config.PollConfig{
+		StatusApi: strings.TrimSuffix(m.Api, "/sdapi/v1/txt2img") + "/status/{id}",
+		Interval:  config.Duration(time.Millisecond),
+		Timeout:   config.Duration(time.Hour),
+	}
+	setupTest(t, map[string]Model{"tts": m})
+
+	createTestTask(t, `{"model":"tts","prompt":"hello","response_format":"wav"}`)
+	scheduleAndDrain(t)
+	time.Sleep(10 * time.Millisecond)
+	pollJobs(context.Background(), 5)
+
+	detail := getTestTask(t, 1)
+	if detail.Status != int32(Success) || len(detail.Outputs) != 1 || detail.Outputs[0].MimeType != "audio/wav" {
+		t.Errorf("got status %d outputs %+v, want one audio/wav output", detail.Status, detail.Outputs)
+	}
+}
+
+func TestPollJobsWithinDeadline(t *testing.T) {
+	m, _ := a1111Backend(
+		t, func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet {
+				// 状态接口一直不返回
+				select {
+				case <-r.Context().Done():
+				case <-time.After(5 * time.Second):
+				}
+				return
+			}
+			_, _ = w.Write([]byte(`{"id":"job"}`))
+		},
+	)
+	m.Retries = 0
+	m.Poll = config.PollConfig{
+		StatusApi: strings.TrimSuffix(m.Api, "/sdapi/v1/txt2img") + "/status/{id}",
+		Interval:  config.Duration(time.Millisecond),
+		Timeout:   config.Duration(time.Hour),
+	}
+	setupTest(t, map[string]Model{"sd": m})
+
+	createTestTask(t, `{"model":"sd","prompt":"cat"}`)
+	scheduleAndDrain(t)
+	time.Sleep(10 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 400*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := Schedule(ctx, 5); err != nil {
+		t.Fatalf("schedule: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 300*time.Millisecond {
+		t.Errorf("schedule took %s, want polling to stop at half of the deadline", elapsed)
+	}
+	if status := getTestTask(t, 1).Status; status != int32(Running) {
+		t.Errorf("got status %d, want the job to be polled again", status)
+	}
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"net/http"
+	"severless-task-scheduler/tracing"
+	"sort"
+)
+
+const (
+	HealthConnected    = "connected"
+	HealthDisconnected = "disconnected"
+)
+
+// ModelInfo 对外展示的模型信息，不包含 Api 等内部字段
+type ModelInfo struct {
+	Name        string               `json:"name"`
+	DisplayName string               `json:"display_name"`
+	Parameters  map[string]*Property `json:"parameters"`
+	Outputs     []string             `json:"outputs"`
+	MaxWidth    int                  `json:"max_width"`
+	MaxHeight   int                  `json:"max_height"`
+	Health      string               `json:"health"`
+	QueueDepth  int64                `json:"queue_depth"`
+}
+
+func ListModels(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracing.StartHandler(r, "ListModels")
+	defer span.End()
+
+	counts, err := taskRepository.CountByStatus(ctx, Init)
+	if err != nil {
+		responseError(w, err)
+		return
+	}
+	queueDepth := counts[Init]
+
+	snapshot := Models()
+	infos := make([]ModelInfo, 0, len(snapshot))
+	for name, m := range snapshot {
+		info := ModelInfo{
+			Name:        name,
+			DisplayName: m.DisplayName,
+			Health:      HealthDisconnected,
+			QueueDepth:  queueDepth[name],
+		}
+		if info.DisplayName == "" {
+			info.DisplayName = name
+		}
+		if requestReflect, ok := requestType(m); ok {
+			info.Outputs = outputKinds(newPredictRequest(requestReflect))
+		}
+		if schema, ok := lookupModelSchema(name); ok {
+			info.Parameters = schema.Properties
+			if width, ok := schema.Properties["width"]; ok && width.Maximum != nil {
+				info.MaxWidth = *width.Maximum
+			}
+			if height, ok := schema.Properties["height"]; ok && height.Maximum != nil {
+				info.MaxHeight = *height.Maximum
+			}
+		}
+		if b, ok := lookupBackend(name); ok && b.transport.Connected() {
+			info.Health = HealthConnected
+		}
+		infos = append(infos, info)
+	}
+	sort.Slice(
+		infos, func(i, j int) bool {
+			return infos[i].Name < infos[j].Name
+		},
+	)
+	responseData(w, infos)
+}
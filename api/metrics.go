@@ -0,0 +1,26 @@
+package api
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"severless-task-scheduler/db/model"
+	"severless-task-scheduler/metrics"
+)
+
+// repositoryCounter 让指标始终使用当前的 taskRepository
+type repositoryCounter struct{}
+
+func (repositoryCounter) CountByStatus(statuses ...model.Status) (map[model.Status]map[string]int64, error) {
+	return taskRepository.CountByStatus(context.Background(), statuses...)
+}
+
+func init() {
+	metrics.RegisterTaskCollector(repositoryCounter{})
+}
+
+var metricsHandler = promhttp.Handler()
+
+func Metrics(w http.ResponseWriter, r *http.Request) {
+	metricsHandler.ServeHTTP(w, r)
+}
@@ -0,0 +1,286 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"os"
+	"reflect"
+	"severless-task-scheduler/config"
+	"severless-task-scheduler/db/model"
+	"severless-task-scheduler/logging"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var modelsMu sync.RWMutex
+var models map[string]Model
+
+// disabledModels t_model 中已停用的模型，它们的任务保持排队
+var disabledModels map[string]bool
+
+// reloadMu 保证同一时间只有一次模型重新加载
+var reloadMu sync.Mutex
+
+// ModelChanges 一次重新加载中发生变化的模型
+type ModelChanges struct {
+	Added       []string `json:"added"`
+	Removed     []string `json:"removed"`
+	Reconnected []string `json:"reconnected"`
+	Updated     []string `json:"updated"`
+}
+
+func lookupModel(name string) (Model, bool) {
+	modelsMu.RLock()
+	defer modelsMu.RUnlock()
+	m, ok := models[name]
+	return m, ok
+}
+
+// Models 返回当前所有模型的副本
+func Models() map[string]Model {
+	modelsMu.RLock()
+	defer modelsMu.RUnlock()
+	snapshot := make(map[string]Model, len(models))
+	for name, m := range models {
+		snapshot[name] = m
+	}
+	return snapshot
+}
+
+// ApplyModels 把模型集合替换为 next：新增的模型建立连接，删除的模型等执行中的调用结束后断开，
+// 连接参数变化的模型重新连接，旧连接同样等调用结束后断开。
+// 新的后端在模型生效前建立，调度时能找到的模型一定有后端
+func ApplyModels(next map[string]Model) ModelChanges {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+
+	// 运行期间只有 ApplyModels 在持有 reloadMu 时替换 models，可以直接读取
+	previous := models
+
+	changes := ModelChanges{
+		Added:       make([]string, 0),
+		Removed:     make([]string, 0),
+		Reconnected: make([]string, 0),
+		Updated:     make([]string, 0),
+	}
+	retired := make(map[string]*backend)
+	for name, m := range next {
+		old, ok := previous[name]
+		switch {
+		case !ok:
+			startBackend(name, m)
+			changes.Added = append(changes.Added, name)
+		case connectionChanged(old, m):
+			if b, ok := lookupBackend(name); ok {
+				retired[name] = b
+			}
+			startBackend(name, m)
+			changes.Reconnected = append(changes.Reconnected, name)
+		case !reflect.DeepEqual(old, m):
+			changes.Updated = append(changes.Updated, name)
+		}
+	}
+
+	modelsMu.Lock()
+	models = next
+	modelsMu.Unlock()
+
+	for name := range previous {
+		if _, ok := next[name]; ok {
+			continue
+		}
+		if b, ok := lookupBackend(name); ok {
+			retired[name] = b
+		}
+		changes.Removed = append(changes.Removed, name)
+	}
+	for name, b := range retired {
+		retireBackend(name, b)
+	}
+	changed := false
+	for _, names := range [][]string{changes.Added, changes.Removed, changes.Reconnected, changes.Updated} {
+		sort.Strings(names)
+		changed = changed || len(names) > 0
+	}
+	if changed {
+		logrus.WithField("changes", changes).Info("models reloaded")
+	}
+	return changes
+}
+
+// connectionChanged 连接相关的配置变化后需要重新连接
+func connectionChanged(old Model, m Model) bool {
+	return old.Api != m.Api ||
+		old.Transport != m.Transport ||
+		old.Retries != m.Retries ||
+		old.RetryBackoff != m.RetryBackoff ||
+		!reflect.DeepEqual(old.Headers, m.Headers) ||
+		old.Auth != m.Auth ||
+		old.TLS != m.TLS ||
+		old.Poll != m.Poll ||
+		old.ReadTimeout != m.ReadTimeout ||
+		old.HeartbeatPeriod != m.HeartbeatPeriod ||
+		old.MaxReadSize != m.MaxReadSize
+}
+
+// modelQuotas 限制了并发的模型还可以派发的任务数
+func modelQuotas() map[string]int {
+	quotas := make(map[string]int)
+	modelsMu.RLock()
+	for name := range disabledModels {
+		quotas[name] = 0
+	}
+	modelsMu.RUnlock()
+	for name, m := range Models() {
+		if m.Concurrency <= 0 {
+			continue
+		}
+		quota := m.Concurrency
+		if b, ok := lookupBackend(name); ok {
+			quota -= int(atomic.LoadInt64(&b.inflight))
+		}
+		quotas[name] = quota
+	}
+	return quotas
+}
+
+// modelFromRecord 把 t_model 的记录转换为模型配置
+func modelFromRecord(record *model.RegisteredModel) (Model, error) {
+	m := Model{
+		Name:        record.Name,
+		DisplayName: record.DisplayName,
+		Adapter:     record.Adapter,
+		Api:         record.API,
+		Transport:   record.Transport,
+		Retries:     int(record.Retries),
+		Version:     record.Version,
+		Cache:       record.Cache,
+		Concurrency: int(record.Concurrency),
+	}
+	if record.DefaultParameters != nil {
+		if err := json.Unmarshal([]byte(*record.DefaultParameters), &m.Defaults); err != nil {
+			return m, fmt.Errorf("default_parameters is not valid json: %v", err)
+		}
+	}
+	if record.Headers != nil {
+		if err := json.Unmarshal([]byte(*record.Headers), &m.Headers); err != nil {
+			return m, fmt.Errorf("headers is not valid json: %v", err)
+		}
+	}
+	if record.Auth != nil {
+		if err := json.Unmarshal([]byte(*record.Auth), &m.Auth); err != nil {
+			return m, fmt.Errorf("auth is not valid json: %v", err)
+		}
+	}
+	if record.TLS != nil {
+		if err := json.Unmarshal([]byte(*record.TLS), &m.TLS); err != nil {
+			return m, fmt.Errorf("tls is not valid json: %v", err)
+		}
+	}
+	if record.Poll != nil {
+		if err := json.Unmarshal([]byte(*record.Poll), &m.Poll); err != nil {
+			return m, fmt.Errorf("poll is not valid json: %v", err)
+		}
+	}
+	return m.WithDefaults(record.Name), nil
+}
+
+// loadRegistry 从 t_model 读取所有启用的模型，无法解析的记录会被跳过
+func loadRegistry(ctx context.Context) (map[string]Model, error) {
+	records, err := modelRepository.List(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	registry := make(map[string]Model, len(records))
+	disabled := make(map[string]bool)
+	for _, record := range records {
+		if !record.Enabled {
+			disabled[record.Name] = true
+			continue
+		}
+		m, err := modelFromRecord(record)
+		if err != nil {
+			logrus.WithField(logging.FieldModel, record.Name).WithError(err).Error("invalid model record")
+			continue
+		}
+		registry[record.Name] = m
+	}
+	modelsMu.Lock()
+	disabledModels = disabled
+	modelsMu.Unlock()
+	return registry, nil
+}
+
+// ReloadRegistry 按模型来源重新加载模型
+func ReloadRegistry(ctx context.Context) (ModelChanges, error) {
+	if cfg.Registry.Source != config.RegistrySourceDatabase {
+		return ReloadConfig()
+	}
+	registry, err := loadRegistry(ctx)
+	if err != nil {
+		return ModelChanges{}, err
+	}
+	return ApplyModels(registry), nil
+}
+
+// WatchRegistry 每隔 interval 从数据库刷新模型，使多个实例的模型保持一致，直到 ctx 被取消
+func WatchRegistry(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if _, err := ReloadRegistry(ctx); err != nil {
+			logrus.WithError(err).Error("refresh model registry error")
+		}
+	}
+}
+
+// ReloadConfig 重新读取配置并应用其中的模型，配置无效或没有模型时保留当前的模型
+func ReloadConfig() (ModelChanges, error) {
+	c, err := config.Reload()
+	if err != nil {
+		return ModelChanges{}, NewValidationError(err.Error())
+	}
+	if len(c.Models) == 0 {
+		return ModelChanges{}, NewValidationError("no model configured")
+	}
+	return ApplyModels(c.Models), nil
+}
+
+// WatchConfig 每隔 interval 检查配置文件，修改时间或大小变化后重新加载模型，直到 ctx 被取消
+func WatchConfig(ctx context.Context, path string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var modTime time.Time
+	var size int64
+	if info, err := os.Stat(path); err == nil {
+		modTime, size = info.ModTime(), info.Size()
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			logrus.WithError(err).Error("stat config file error")
+			continue
+		}
+		if info.ModTime().Equal(modTime) && info.Size() == size {
+			continue
+		}
+		modTime, size = info.ModTime(), info.Size()
+		if _, err = ReloadConfig(); err != nil {
+			logrus.WithField("path", path).WithError(err).Error("reload config error, keep current models")
+		}
+	}
+}
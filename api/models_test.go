@@ -0,0 +1,65 @@
+package api
+
+import (
+	"github.com/gorilla/websocket"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestApplyModelsStartsBackendsFirst(t *testing.T) {
+	// 握手较慢的 websocket 后端，放大建立连接的时间
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				time.Sleep(20 * time.Millisecond)
+				connection, err := upgrader.Upgrade(w, r, nil)
+				if err != nil {
+					return
+				}
+				defer connection.Close()
+				for {
+					if _, _, err = connection.ReadMessage(); err != nil {
+						return
+					}
+				}
+			},
+		),
+	)
+	t.Cleanup(server.Close)
+	setupTest(t, map[string]Model{})
+
+	stop := make(chan struct{})
+	missing := 0
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			if _, ok := lookupModel("openjourney"); ok {
+				if _, ok = lookupBackend("openjourney"); !ok {
+					missing++
+				}
+			}
+		}
+	}()
+	m := Model{Api: "ws" + strings.TrimPrefix(server.URL, "http")}
+	for i := 0; i < 5; i++ {
+		ApplyModels(map[string]Model{"openjourney": m.WithDefaults("openjourney")})
+		ApplyModels(map[string]Model{})
+	}
+	close(stop)
+	wg.Wait()
+	if missing > 0 {
+		t.Errorf("model without a backend was visible %d times", missing)
+	}
+}
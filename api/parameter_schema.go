@@ -0,0 +1,204 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"unicode/utf8"
+)
+
+// Schema 是任务参数的 JSON Schema 描述，只实现了本项目用到的关键字
+type Schema struct {
+	Type       string               `json:"type"`
+	Properties map[string]*Property `json:"properties"`
+	Required   []string             `json:"required"`
+}
+
+type Property struct {
+	Type       string   `json:"type"`
+	Enum       []string `json:"enum,omitempty"`
+	MinLength  *int     `json:"minLength,omitempty"`
+	MaxLength  *int     `json:"maxLength,omitempty"`
+	Minimum    *int     `json:"minimum,omitempty"`
+	Maximum    *int     `json:"maximum,omitempty"`
+	MultipleOf *int     `json:"multipleOf,omitempty"`
+	Default    any      `json:"default,omitempty"`
+}
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Validate 按 schema 校验参数，返回按字段名排序的错误列表
+func (s *Schema) Validate(content map[string]any) []FieldError {
+	fieldErrors := make([]FieldError, 0)
+	for _, name := range s.Required {
+		if _, ok := content[name]; !ok {
+			fieldErrors = append(fieldErrors, FieldError{Field: name, Message: "is required"})
+		}
+	}
+	for name, value := range content {
+		property, ok := s.Properties[name]
+		if !ok {
+			fieldErrors = append(fieldErrors, FieldError{Field: name, Message: "is not supported"})
+			continue
+		}
+		if message := property.validate(value); message != "" {
+			fieldErrors = append(fieldErrors, FieldError{Field: name, Message: message})
+		}
+	}
+	sort.Slice(
+		fieldErrors, func(i, j int) bool {
+			return fieldErrors[i].Field < fieldErrors[j].Field
+		},
+	)
+	return fieldErrors
+}
+
+func (p *Property) validate(value any) string {
+	switch p.Type {
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return "must be a string"
+		}
+		length := utf8.RuneCountInString(str)
+		if p.MinLength != nil && length < *p.MinLength {
+			return fmt.Sprintf("must be at least %d characters", *p.MinLength)
+		}
+		if p.MaxLength != nil && length > *p.MaxLength {
+			return fmt.Sprintf("must be at most %d characters", *p.MaxLength)
+		}
+		if len(p.Enum) > 0 {
+			for _, e := range p.Enum {
+				if e == str {
+					return ""
+				}
+			}
+			return fmt.Sprintf("must be one of %v", p.Enum)
+		}
+	case "integer":
+		number, ok := value.(float64)
+		if !ok || number != math.Trunc(number) {
+			return "must be an integer"
+		}
+		if p.Minimum != nil && number < float64(*p.Minimum) {
+			return fmt.Sprintf("must be greater than or equal to %d", *p.Minimum)
+		}
+		if p.Maximum != nil && number > float64(*p.Maximum) {
+			return fmt.Sprintf("must be less than or equal to %d", *p.Maximum)
+		}
+		if p.MultipleOf != nil && int64(number)%int64(*p.MultipleOf) != 0 {
+			return fmt.Sprintf("must be a multiple of %d", *p.MultipleOf)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return "must be a boolean"
+		}
+	case "number":
+		number, ok := value.(float64)
+		if !ok {
+			return "must be a number"
+		}
+		if p.Minimum != nil && number < float64(*p.Minimum) {
+			return fmt.Sprintf("must be greater than or equal to %d", *p.Minimum)
+		}
+		if p.Maximum != nil && number > float64(*p.Maximum) {
+			return fmt.Sprintf("must be less than or equal to %d", *p.Maximum)
+		}
+	}
+	return ""
+}
+
+// lookupModelSchema 获取模型的参数 schema
+func lookupModelSchema(modelName string) (*Schema, bool) {
+	m, ok := lookupModel(modelName)
+	if !ok {
+		return nil, false
+	}
+	requestReflect, ok := requestType(m)
+	if !ok {
+		return nil, false
+	}
+	schema := requestSchema(m, newPredictRequest(requestReflect))
+	// 模型配置可以覆盖默认值和取值范围
+	for name, value := range m.Defaults {
+		if property, ok := schema.Properties[name]; ok {
+			property.Default = value
+		}
+	}
+	for name, limit := range m.Limits {
+		if property, ok := schema.Properties[name]; ok {
+			if limit.Minimum != nil {
+				property.Minimum = limit.Minimum
+			}
+			if limit.Maximum != nil {
+				property.Maximum = limit.Maximum
+			}
+		}
+	}
+	return schema, true
+}
+
+// requestSchema 模型请求的参数 schema，实现了 ImageRequest 的请求增加 task_type 和 strength，
+// 开启缓存的模型增加 cache
+func requestSchema(m Model, request PredictRequest) *Schema {
+	schema := request.Schema()
+	if _, ok := request.(ImageRequest); ok {
+		for name, property := range imageTaskProperties() {
+			schema.Properties[name] = property
+		}
+	}
+	if _, ok := request.(CacheableRequest); ok && m.Cache {
+		schema.Properties["cache"] = &Property{Type: "boolean", Default: true}
+	}
+	return schema
+}
+
+// applyModelDefaults 把模型配置的默认值补充到未指定的参数中
+func applyModelDefaults(parameter []byte, defaults map[string]any) ([]byte, error) {
+	if len(defaults) == 0 {
+		return parameter, nil
+	}
+	content := make(map[string]any)
+	err := json.Unmarshal(parameter, &content)
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range defaults {
+		if _, ok := content[name]; !ok {
+			content[name] = value
+		}
+	}
+	return json.Marshal(content)
+}
+
+// validateTaskParameter 校验创建任务时提交的参数
+func validateTaskParameter(body []byte) (*TaskParameter, []FieldError) {
+	content := make(map[string]any)
+	err := json.Unmarshal(body, &content)
+	if err != nil {
+		return nil, []FieldError{{Field: "", Message: fmt.Sprintf("invalid json: %v", err)}}
+	}
+	modelName, ok := content["model"].(string)
+	if !ok || modelName == "" {
+		return nil, []FieldError{{Field: "model", Message: "is required"}}
+	}
+	schema, ok := lookupModelSchema(modelName)
+	if !ok {
+		return nil, []FieldError{{Field: "model", Message: fmt.Sprintf("model %s not found", modelName)}}
+	}
+	fieldErrors := schema.Validate(content)
+	if len(fieldErrors) > 0 {
+		return nil, fieldErrors
+	}
+
+	parameter := TaskParameter{}
+	err = json.Unmarshal(body, &parameter)
+	if err != nil {
+		return nil, []FieldError{{Field: "", Message: fmt.Sprintf("invalid json: %v", err)}}
+	}
+	return &parameter, nil
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+const ReadyzDBTimeout = 2 * time.Second
+
+// ReadinessReport 就绪检查的结果，数据库不可用时返回 503
+type ReadinessReport struct {
+	Ready    bool                    `json:"ready"`
+	Database string                  `json:"database"`
+	Models   map[string]BackendState `json:"models"`
+}
+
+func Readyz(w http.ResponseWriter, r *http.Request) {
+	snapshot := Models()
+	report := ReadinessReport{
+		Ready:    true,
+		Database: "ok",
+		Models:   make(map[string]BackendState, len(snapshot)),
+	}
+	if err := pingDB(r.Context()); err != nil {
+		report.Ready = false
+		report.Database = err.Error()
+	}
+	states := GetBackendStates()
+	for name := range snapshot {
+		report.Models[name] = states[name]
+	}
+
+	status := http.StatusOK
+	responseBody := map[string]any{
+		"code":    http.StatusOK,
+		"message": "success",
+		"data":    report,
+	}
+	if !report.Ready {
+		status = http.StatusServiceUnavailable
+		responseBody["code"] = CodeUnavailable
+		responseBody["message"] = "not ready"
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	responseBytes, _ := json.Marshal(responseBody)
+	w.Write(responseBytes)
+}
+
+func pingDB(ctx context.Context) error {
+	if db == nil {
+		return dbErr
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, ReadyzDBTimeout)
+	defer cancel()
+	return sqlDB.PingContext(ctx)
+}
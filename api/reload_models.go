@@ -0,0 +1,21 @@
+package api
+
+import (
+	"net/http"
+	"severless-task-scheduler/logging"
+	"severless-task-scheduler/tracing"
+)
+
+// ReloadModels 从配置文件或 t_model 重新加载模型，返回发生变化的模型
+func ReloadModels(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracing.StartHandler(r, "ReloadModels")
+	defer span.End()
+
+	changes, err := ReloadRegistry(ctx)
+	if err != nil {
+		logging.FromContext(ctx).WithError(err).Error("reload models error")
+		responseError(w, err)
+		return
+	}
+	responseData(w, changes)
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"gorm.io/gorm"
+	"severless-task-scheduler/db/model"
+	"severless-task-scheduler/logging"
+	"severless-task-scheduler/metrics"
+)
+
+// CacheableRequest 结果可能只由参数决定的请求。CacheKey 返回去掉任务 ID 等字段后的请求 JSON，
+// 结果不确定（如随机种子）时返回 false
+type CacheableRequest interface {
+	CacheKey() ([]byte, bool)
+}
+
+// cacheKey 请求的缓存键，为模型名称、版本和规范化的请求 JSON 的 sha256，模型未开启缓存或结果不确定时返回 false
+func cacheKey(m Model, request PredictRequest) (string, bool) {
+	cacheable, ok := request.(CacheableRequest)
+	if !ok || !m.Cache {
+		return "", false
+	}
+	normalized, ok := cacheable.CacheKey()
+	if !ok {
+		return "", false
+	}
+	hash := sha256.New()
+	hash.Write([]byte(m.Name + "\x00" + m.Version + "\x00"))
+	hash.Write(normalized)
+	return hex.EncodeToString(hash.Sum(nil)), true
+}
+
+// cacheDisabled 任务参数中的 cache 为 false 时不复用已有的结果，任务自己的结果仍然可以被复用
+func cacheDisabled(parameter []byte) bool {
+	content := struct {
+		Cache *bool `json:"cache"`
+	}{}
+	return json.Unmarshal(parameter, &content) == nil && content.Cache != nil && !*content.Cache
+}
+
+// prepareCache 创建任务时按当前的模型配置计算缓存键，不可缓存时返回空字符串，reuse 表示是否查找已有的结果
+func prepareCache(parameter []byte, modelName string, inputs []*model.TaskInput) (string, bool) {
+	m, ok := lookupModel(modelName)
+	if !ok || !m.Cache {
+		return "", false
+	}
+	requestReflect, ok := requestType(m)
+	if !ok {
+		return "", false
+	}
+	request := newPredictRequest(requestReflect)
+	parameter, err := applyModelDefaults(parameter, m.Defaults)
+	if err == nil {
+		err = request.Parse(bytes.NewReader(parameter), &model.Task{})
+	}
+	if err != nil {
+		return "", false
+	}
+	// 输入图片是请求的一部分，与派发时一样以 data url 计入缓存键
+	if imageRequest, ok := request.(ImageRequest); ok && taskTypeOf(parameter) != TaskTypeTxt2Img {
+		imageRequest.SetInputs(inputs)
+	}
+	key, ok := cacheKey(m, request)
+	if !ok {
+		return "", false
+	}
+	return key, !cacheDisabled(parameter)
+}
+
+// findCached 查找缓存键相同的成功任务，返回其 ID，没有找到时返回 0
+func findCached(ctx context.Context, key string) int64 {
+	source, err := taskRepository.FindCached(ctx, key)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0
+	}
+	if err != nil {
+		logging.FromContext(ctx).WithError(err).Error("find cached task error")
+		return 0
+	}
+	return source.ID
+}
+
+// reuseResult 把缓存键相同的成功任务的结果复制到任务 id，返回被复用的任务 ID，没有复用时返回 0
+func reuseResult(ctx context.Context, modelName string, id int64, key string, stage string) int64 {
+	sourceID := findCached(ctx, key)
+	if sourceID == 0 {
+		return 0
+	}
+	copied, err := taskRepository.CopyResult(ctx, id, sourceID)
+	if err != nil {
+		logging.FromContext(ctx).WithError(err).Error("copy cached result error")
+		return 0
+	}
+	if !copied {
+		return 0
+	}
+	metrics.CacheHits.WithLabelValues(modelName, stage).Inc()
+	logging.FromContext(ctx).WithField("cached_from", sourceID).Info("task result reused")
+	return sourceID
+}
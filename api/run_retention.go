@@ -0,0 +1,20 @@
+package api
+
+import (
+	"net/http"
+	"severless-task-scheduler/retention"
+	"severless-task-scheduler/tracing"
+)
+
+// RunRetention 按保留策略清理过期的任务和图片，返回 done 为 false 时需要再次调用
+func RunRetention(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracing.StartHandler(r, "RunRetention")
+	defer span.End()
+
+	report, err := retention.NewRunner(taskRepository, retention.ConfigFrom(cfg.Retention)).Run(ctx)
+	if err != nil {
+		responseError(w, err)
+		return
+	}
+	responseData(w, report)
+}
@@ -2,24 +2,40 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"github.com/gorilla/websocket"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"io"
+	"math"
 	"net/http"
-	"os"
-	"os/signal"
 	"reflect"
+	"severless-task-scheduler/config"
 	"severless-task-scheduler/db/model"
-	"strconv"
+	"severless-task-scheduler/logging"
+	"severless-task-scheduler/metrics"
+	"severless-task-scheduler/tracing"
 	"time"
 )
 
 type PredictRequest interface {
 	Parse(r io.Reader, task *model.Task) error
 	Json() []byte
+	Schema() *Schema
+}
+
+// TraceRequest 可以把 trace id 传给模型后端的请求
+type TraceRequest interface {
+	SetTraceID(traceID string)
+}
+
+// ResponseDecoder 响应不是图片数组的请求自行从响应中取出图片，产生其他输出的请求实现 ArtifactDecoder
+type ResponseDecoder interface {
+	Decode(message []byte) ([]string, error)
 }
 
 type GradioRequest struct {
@@ -31,6 +47,27 @@ type GradioRequest struct {
 	Height            int    `json:"height"`
 	GuidanceScale     int    `json:"guidance_scale"`
 	RandSeed          int    `json:"rand_seed"`
+	TraceID           string `json:"trace_id,omitempty"`
+	// TaskType 等字段只在 img2img 和 inpaint 时发送，图片为 data url
+	TaskType  string   `json:"task_type,omitempty"`
+	Strength  *float64 `json:"strength,omitempty"`
+	InitImage string   `json:"init_image,omitempty"`
+	Mask      string   `json:"mask,omitempty"`
+}
+
+func (g *GradioRequest) SetTraceID(traceID string) {
+	g.TraceID = traceID
+}
+
+// CacheKey 固定随机种子时结果只由参数决定，去掉任务 ID 和 trace id
+func (g *GradioRequest) CacheKey() ([]byte, bool) {
+	if g.RandSeed < 0 {
+		return nil, false
+	}
+	normalized := *g
+	normalized.TaskID = 0
+	normalized.TraceID = ""
+	return normalized.Json(), true
 }
 
 func (g *GradioRequest) Json() []byte {
@@ -43,39 +80,140 @@ func (g *GradioRequest) Parse(r io.Reader, task *model.Task) error {
 	if err != nil {
 		return err
 	}
-	content := make(map[string]any)
-	err = json.Unmarshal(all, &content)
+	parameter := TaskParameter{}
+	err = json.Unmarshal(all, &parameter)
 	if err != nil {
 		return err
 	}
 	g.TaskID = task.ID
-	g.Prompt = content["prompt"].(string)
+	g.Prompt = parameter.Prompt
 	g.NegativePrompt = DefaultNegativePrompt
 	g.NumInferenceSteps = DefaultNumInferenceSteps
 	g.Width = DefaultWidth
 	g.Height = DefaultHeight
 	g.GuidanceScale = DefaultGuidanceScale
 	g.RandSeed = DefaultRandSeed
+	if parameter.NegativePrompt != nil {
+		g.NegativePrompt = *parameter.NegativePrompt
+	}
+	if parameter.NumInferenceSteps != nil {
+		g.NumInferenceSteps = *parameter.NumInferenceSteps
+	}
+	if parameter.Width != nil {
+		g.Width = *parameter.Width
+	}
+	if parameter.Height != nil {
+		g.Height = *parameter.Height
+	}
+	if parameter.GuidanceScale != nil {
+		g.GuidanceScale = *parameter.GuidanceScale
+	}
+	if parameter.RandSeed != nil {
+		g.RandSeed = *parameter.RandSeed
+	}
+	if parameter.TaskType != nil && *parameter.TaskType != TaskTypeTxt2Img {
+		g.TaskType = *parameter.TaskType
+		g.Strength = parameter.Strength
+		if g.Strength == nil {
+			strength := DefaultStrength
+			g.Strength = &strength
+		}
+	}
 	return nil
 }
 
-type Model struct {
-	Name string `json:"name"`
-	Api  string `json:"api"`
+func (g *GradioRequest) SetInputs(inputs []*model.TaskInput) {
+	if input := findInput(inputs, InputInitImage); input != nil {
+		g.InitImage = dataURL(input)
+	}
+	if input := findInput(inputs, InputMask); input != nil {
+		g.Mask = dataURL(input)
+	}
 }
 
+func (g *GradioRequest) Schema() *Schema {
+	return &Schema{
+		Type: "object",
+		Properties: map[string]*Property{
+			"model": {
+				Type: "string",
+			},
+			"prompt": {
+				Type:      "string",
+				MinLength: IntPtr(1),
+				MaxLength: IntPtr(MaxPromptLength),
+			},
+			"negative_prompt": {
+				Type:      "string",
+				MaxLength: IntPtr(MaxPromptLength),
+				Default:   DefaultNegativePrompt,
+			},
+			"num_inference_steps": {
+				Type:    "integer",
+				Minimum: IntPtr(1),
+				Maximum: IntPtr(100),
+				Default: DefaultNumInferenceSteps,
+			},
+			"width": {
+				Type:       "integer",
+				Minimum:    IntPtr(64),
+				Maximum:    IntPtr(1024),
+				MultipleOf: IntPtr(8),
+				Default:    DefaultWidth,
+			},
+			"height": {
+				Type:       "integer",
+				Minimum:    IntPtr(64),
+				Maximum:    IntPtr(1024),
+				MultipleOf: IntPtr(8),
+				Default:    DefaultHeight,
+			},
+			"guidance_scale": {
+				Type:    "integer",
+				Minimum: IntPtr(1),
+				Maximum: IntPtr(30),
+				Default: DefaultGuidanceScale,
+			},
+			"rand_seed": {
+				Type:    "integer",
+				Minimum: IntPtr(-1),
+				Maximum: IntPtr(math.MaxInt32),
+				Default: DefaultRandSeed,
+			},
+		},
+		Required: []string{"model", "prompt"},
+	}
+}
+
+type Model = config.ModelConfig
+
 type TaskParameter struct {
-	Prompt string `json:"prompt"`
-	Model  string `json:"model"`
+	Prompt            string   `json:"prompt"`
+	Model             string   `json:"model"`
+	NegativePrompt    *string  `json:"negative_prompt,omitempty"`
+	NumInferenceSteps *int     `json:"num_inference_steps,omitempty"`
+	Width             *int     `json:"width,omitempty"`
+	Height            *int     `json:"height,omitempty"`
+	GuidanceScale     *int     `json:"guidance_scale,omitempty"`
+	RandSeed          *int     `json:"rand_seed,omitempty"`
+	SamplerName       *string  `json:"sampler_name,omitempty"`
+	BatchSize         *int     `json:"batch_size,omitempty"`
+	TaskType          *string  `json:"task_type,omitempty"`
+	Strength          *float64 `json:"strength,omitempty"`
+	MaxTokens         *int     `json:"max_tokens,omitempty"`
+	Temperature       *float64 `json:"temperature,omitempty"`
+	Voice             *string  `json:"voice,omitempty"`
+	ResponseFormat    *string  `json:"response_format,omitempty"`
+	Cache             *bool    `json:"cache,omitempty"`
 }
 
-type Status int32
+type Status = model.Status
 
 const (
-	Init Status = iota + 1
-	Running
-	Success
-	Fail
+	Init    = model.StatusInit
+	Running = model.StatusRunning
+	Success = model.StatusSuccess
+	Fail    = model.StatusFail
 )
 
 const (
@@ -86,11 +224,18 @@ const (
 	DefaultGuidanceScale     = 7
 	DefaultRandSeed          = -1
 
-	MaxReadSize          = 1024 * 1024
-	ReadWait             = 15 * time.Minute
-	HeartbeatWritePeriod = 10 * time.Second
+	MaxPromptLength = 1000
 )
 
+// adapters 按请求格式选择请求类型
+var adapters = map[string]reflect.Type{
+	config.AdapterGradio:     reflect.TypeOf(GradioRequest{}),
+	config.AdapterA1111:      reflect.TypeOf(A1111Request{}),
+	config.AdapterCompletion: reflect.TypeOf(CompletionRequest{}),
+	config.AdapterSpeech:     reflect.TypeOf(SpeechRequest{}),
+}
+
+// modelRequest 未配置 adapter 的模型按名称选择请求类型
 var modelRequest = map[string]reflect.Type{
 	"openjourney":    reflect.TypeOf(GradioRequest{}),
 	"anything":       reflect.TypeOf(GradioRequest{}),
@@ -98,214 +243,229 @@ var modelRequest = map[string]reflect.Type{
 	"nerverendDream": reflect.TypeOf(GradioRequest{}),
 }
 
-var connections map[string]*websocket.Conn
-
-var models map[string]Model
-
-func init() {
-	models = make(map[string]Model)
-	configJson := os.Getenv("MODEL_CONFIG")
-	if configJson == "" {
-		panic("MODEL_CONFIG is empty")
-	}
-	err := json.Unmarshal([]byte(configJson), &models)
-	if err != nil {
-		panic(err)
-	}
-
-	connections = make(map[string]*websocket.Conn)
-	dialer := websocket.Dialer{}
-	for name, model := range models {
-		connection, _, err := dialer.Dial(
-			model.Api, http.Header{
-				"ngrok-skip-browser-warning": []string{"true"},
-			},
-		)
-		if err != nil {
-			// 更新任务状态为失败
-			logrus.Errorf("connect model api error: %v", err)
-			return
-		}
-
-		go func(connection *websocket.Conn) {
-			connection.SetPongHandler(
-				func(string) error {
-					connection.SetReadDeadline(time.Now().Add(ReadWait))
-					return nil
-				},
-			)
-			timer := time.NewTicker(HeartbeatWritePeriod)
-			defer timer.Stop()
-			for {
-				select {
-				case <-timer.C:
-					if err := connection.WriteMessage(websocket.PingMessage, nil); err != nil {
-						return
-					}
-				}
-			}
-		}(connection)
-		connections[name] = connection
+// requestType 获取模型的请求类型
+func requestType(m Model) (reflect.Type, bool) {
+	if m.Adapter != "" {
+		requestReflect, ok := adapters[m.Adapter]
+		return requestReflect, ok
 	}
-
-	sig := make(chan os.Signal, 1)
-	signal.Notify(sig, os.Interrupt, os.Kill)
-
-	go func() {
-		<-sig
-		for _, connection := range connections {
-			_ = connection.Close()
-		}
-	}()
+	requestReflect, ok := modelRequest[m.Name]
+	return requestReflect, ok
 }
 
-func GetConnection(modelName string) *websocket.Conn {
-	return connections[modelName]
+func newPredictRequest(requestReflect reflect.Type) PredictRequest {
+	return reflect.New(requestReflect).Interface().(PredictRequest)
 }
 
 func ScheduleTask(w http.ResponseWriter, r *http.Request) {
-	// 查询所有状态为1的任务
-	limitConfig := os.Getenv("SCHEDULE_TASK_LIMIT")
-	if limitConfig == "" {
-		logrus.Error("SCHEDULE_TASK_LIMIT is empty")
-		responseError(w, errors.New("SCHEDULE_TASK_LIMIT is empty"))
-		return
-	}
-	limit, err := strconv.Atoi(limitConfig)
+	ctx, span := tracing.StartHandler(r, "ScheduleTask")
+	defer span.End()
+
+	limit, err := ScheduleTaskLimit()
 	if err != nil {
-		logrus.Error("SCHEDULE_TASK_LIMIT is not a number")
+		logging.FromContext(ctx).WithError(err).Error("read schedule task limit error")
 		responseError(w, err)
 		return
 	}
-	tasks, err := query.Task.Where(query.Task.Status.Eq(1)).Order(query.Task.ID).Limit(limit).Find()
+	_, err = Schedule(ctx, limit)
 	if err != nil {
-		logrus.Errorf("query task error: %v", err)
 		responseError(w, err)
 		return
 	}
+	responseEmpty(w)
+}
+
+// ScheduleTaskLimit 读取单次调度的任务数上限
+func ScheduleTaskLimit() (int, error) {
+	if cfg.Scheduler.Limit <= 0 {
+		return 0, NewUnavailableError("scheduler.limit is not configured")
+	}
+	return cfg.Scheduler.Limit, nil
+}
+
+// Schedule 认领最多 limit 个待执行的任务并异步调用模型，返回已派发的任务数
+func Schedule(ctx context.Context, limit int) (int, error) {
+	ctx, span := tracing.Tracer.Start(ctx, "Schedule", trace.WithAttributes(attribute.Int("limit", limit)))
+	defer span.End()
+
+	// 先查询已提交给异步后端的任务，它们不占用调度名额
+	pollJobs(ctx, limit)
+	// 认领状态为待执行的任务
+	tasks, err := taskRepository.Claim(ctx, limit, modelQuotas())
+	if err != nil {
+		logging.FromContext(ctx).WithError(err).Error("claim task error")
+		if len(tasks) == 0 {
+			return 0, err
+		}
+	}
+	dispatched := 0
 	for _, task := range tasks {
+		taskCtx := logging.WithFields(
+			ctx, logrus.Fields{
+				logging.FieldTaskID:  task.ID,
+				logging.FieldModel:   task.Model,
+				logging.FieldUserID:  task.UserID,
+				logging.FieldAttempt: task.Attempts,
+			},
+		)
 		// 生成任务参数
 		taskParameter := TaskParameter{}
 		err = json.Unmarshal([]byte(task.Parameter), &taskParameter)
 		if err != nil {
 			// 更新任务状态为失败
-			_, _ = query.Task.Where(query.Task.ID.Eq(task.ID)).UpdateColumns(
-				model.Task{
-					Status:  int32(Fail),
-					Message: StrPtr(fmt.Sprintf("json unmarshal error: %v", err)),
-				},
-			)
+			failTask(taskCtx, task, task.Model, metrics.ReasonInvalidParameter, fmt.Sprintf("json unmarshal error: %v", err))
+			continue
+		}
+		m, ok := lookupModel(taskParameter.Model)
+		if !ok {
+			// 更新任务状态为失败
+			failTask(taskCtx, task, taskParameter.Model, metrics.ReasonModelNotFound, fmt.Sprintf("model %s not found", taskParameter.Model))
 			continue
 		}
-		m, ok := models[taskParameter.Model]
+		// 派发时确定后端，重新加载模型后执行中的调用仍使用原来的连接
+		b, done, ok := lookupAndTrack(taskParameter.Model)
 		if !ok {
 			// 更新任务状态为失败
-			_, _ = query.Task.Where(query.Task.ID.Eq(task.ID)).UpdateColumns(
-				model.Task{
-					Status:  int32(Fail),
-					Message: StrPtr(fmt.Sprintf("model %s not found", taskParameter.Model)),
-				},
-			)
+			failTask(taskCtx, task, m.Name, metrics.ReasonNoConnection, fmt.Sprintf("get connection error, model: %s", m.Name))
 			continue
 		}
+		metrics.QueueWait.WithLabelValues(m.Name).Observe(time.Since(task.CreatedAt).Seconds())
+		// call 会在请求结束后继续执行，不能继承请求的取消信号，只保留链路和日志字段
+		callCtx := logging.WithFields(trace.ContextWithSpan(context.Background(), span), logging.Fields(taskCtx))
 		// 调用模型API
-		go call(m, task)
+		inflight.Add(1)
+		go func(m Model, b *backend, task *model.Task) {
+			defer inflight.Done()
+			defer done()
+			start := time.Now()
+			status := call(callCtx, m, b, task)
+			// 异步任务在轮询到结果时再统计
+			if status != Running {
+				metrics.GenerationTime.WithLabelValues(m.Name, status.String()).Observe(time.Since(start).Seconds())
+			}
+		}(m, b, task)
+		dispatched++
 	}
-	responseEmpty(w)
+	span.SetAttributes(attribute.Int("dispatched", dispatched))
+	return dispatched, nil
 }
 
-func call(m Model, task *model.Task) {
-	// 更新任务状态为执行中
-	_, _ = query.Task.Where(query.Task.ID.Eq(task.ID)).UpdateColumn(query.Task.Status, int32(Running))
+// failTask 记录失败原因并将任务状态置为失败
+func failTask(ctx context.Context, task *model.Task, modelName string, reason string, message string) {
+	logging.FromContext(ctx).WithField("reason", reason).Errorf("task failed: %s", message)
+	metrics.TaskFailures.WithLabelValues(modelName, reason).Inc()
+	trace.SpanFromContext(ctx).SetStatus(codes.Error, message)
+	err := taskRepository.UpdateStatus(ctx, task.ID, Fail, StrPtr(message))
+	if err != nil {
+		logging.FromContext(ctx).WithError(err).Error("update task error")
+	}
+}
 
-	requestReflect, ok := modelRequest[m.Name]
-	if !ok {
-		// 更新任务状态为失败
-		logrus.Errorf("model requestReflect %s not found", m.Name)
-		_, _ = query.Task.Where(query.Task.ID.Eq(task.ID)).UpdateColumns(
-			model.Task{
-				Status:  int32(Fail),
-				Message: StrPtr(fmt.Sprintf("model requestReflect %s not found", m.Name)),
-			},
-		)
-		return
+// call 调用模型并保存结果，返回任务的最终状态。异步后端提交成功后返回 Running，结果由 pollJobs 保存
+func call(ctx context.Context, m Model, b *backend, task *model.Task) Status {
+	options := []trace.SpanStartOption{
+		trace.WithAttributes(attribute.Int64("task.id", task.ID), attribute.String("model", m.Name)),
+	}
+	// 关联创建任务的请求
+	if task.TraceParent != nil {
+		if link, ok := tracing.LinkFromTraceParent(*task.TraceParent); ok {
+			options = append(options, trace.WithLinks(link))
+		}
 	}
-	request := reflect.New(requestReflect).Interface().(PredictRequest)
-	err := request.Parse(bytes.NewReader([]byte(task.Parameter)), task)
+	ctx, span := tracing.Tracer.Start(ctx, "call", options...)
+	defer span.End()
+
+	request, parameter, reason, err := buildRequest(ctx, m, task)
 	if err != nil {
 		// 更新任务状态为失败
-		logrus.Errorf("parse task parameter error: %v", err)
-		_, _ = query.Task.Where(query.Task.ID.Eq(task.ID)).UpdateColumns(
-			model.Task{
-				Status:  int32(Fail),
-				Message: StrPtr(fmt.Sprintf("parse task parameter error: %v", err)),
-			},
-		)
-		return
+		failTask(ctx, task, m.Name, reason, err.Error())
+		return Fail
+	}
+	if key, ok := cacheKey(m, request); ok {
+		// 创建任务后模型的版本或默认值可能已经变化，以派发时的缓存键为准
+		if task.CacheKey == nil || *task.CacheKey != key {
+			if err := taskRepository.SetCacheKey(ctx, task.ID, &key); err != nil {
+				logging.FromContext(ctx).WithError(err).Error("update task cache key error")
+			}
+		}
+		// 排队期间相同的请求已经成功时直接复用结果
+		if !cacheDisabled(parameter) && reuseResult(ctx, m.Name, task.ID, key, metrics.StageDispatch) != 0 {
+			return Success
+		}
 	}
 
-	connect := GetConnection(m.Name)
-	if connect == nil {
-		// 更新任务状态为失败
-		logrus.Errorf("get connection error, model: %s", m.Name)
-		_, _ = query.Task.Where(query.Task.ID.Eq(task.ID)).UpdateColumns(
-			model.Task{
-				Status:  int32(Fail),
-				Message: StrPtr(fmt.Sprintf("get connection error, model: %s", m.Name)),
-			},
-		)
-		return
+	if m.Poll.Async() {
+		return submit(ctx, m, b, task, request)
 	}
-	err = connect.WriteMessage(websocket.TextMessage, request.Json())
+	message, err := b.transport.Call(ctx, request)
 	if err != nil {
 		// 更新任务状态为失败
-		logrus.Errorf("write message error: %v", err)
-		_, _ = query.Task.Where(query.Task.ID.Eq(task.ID)).UpdateColumns(
-			model.Task{
-				Status:  int32(Fail),
-				Message: StrPtr(fmt.Sprintf("write message error: %v", err)),
-			},
-		)
-		return
+		failTask(ctx, task, m.Name, transportReason(err), err.Error())
+		return Fail
+	}
+	return saveResult(ctx, m, task, request, message)
+}
+
+// buildRequest 按模型的默认值解析任务参数并加载输入图片，返回请求和应用默认值后的参数，失败时同时返回失败原因
+func buildRequest(ctx context.Context, m Model, task *model.Task) (PredictRequest, []byte, string, error) {
+	requestReflect, ok := requestType(m)
+	if !ok {
+		return nil, nil, metrics.ReasonModelNotFound, fmt.Errorf("model requestReflect %s not found", m.Name)
 	}
-	connect.SetReadLimit(MaxReadSize)
-	connect.SetReadDeadline(time.Now().Add(ReadWait))
-	messageType, message, err := connect.ReadMessage()
+	_, phase := tracing.Tracer.Start(ctx, "call.parse")
+	request := newPredictRequest(requestReflect)
+	parameter, err := applyModelDefaults([]byte(task.Parameter), m.Defaults)
+	if err == nil {
+		err = request.Parse(bytes.NewReader(parameter), task)
+	}
+	tracing.End(phase, err)
 	if err != nil {
-		// 更新任务状态为失败
-		logrus.Errorf("read message error: %v", err)
-		_, _ = query.Task.Where(query.Task.ID.Eq(task.ID)).UpdateColumns(
-			model.Task{
-				Status:  int32(Fail),
-				Message: StrPtr(fmt.Sprintf("read message error: %v", err)),
-			},
-		)
-		return
+		return nil, nil, metrics.ReasonInvalidParameter, fmt.Errorf("parse task parameter error: %v", err)
 	}
-
-	if messageType == websocket.TextMessage {
-		images := make([]string, 0)
-		err = json.Unmarshal(message, &images)
-		if err != nil {
-			// 更新任务状态为失败
-			logrus.Errorf("json unmarshal error: %v", err)
-			_, _ = query.Task.Where(query.Task.ID.Eq(task.ID)).UpdateColumns(
-				model.Task{
-					Status:  int32(Fail),
-					Message: StrPtr(fmt.Sprintf("json unmarshal error: %v", err)),
-				},
-			)
-			return
-		}
-		// 更新任务状态为成功
-		_, err = query.Task.Where(query.Task.ID.Eq(task.ID)).UpdateColumns(
-			model.Task{
-				Status: int32(Success),
-				Image1: BytesPtr([]byte(images[0])),
-			},
-		)
+	if traceRequest, ok := request.(TraceRequest); ok {
+		traceRequest.SetTraceID(tracing.TraceID(ctx))
+	}
+	if imageRequest, ok := request.(ImageRequest); ok && taskTypeOf(parameter) != TaskTypeTxt2Img {
+		inputCtx, phase := tracing.Tracer.Start(ctx, "call.inputs")
+		inputs, err := taskRepository.ListInputs(inputCtx, task.ID)
+		tracing.End(phase, err)
 		if err != nil {
-			logrus.Errorf("update task error: %v", err)
+			return nil, nil, metrics.ReasonInput, fmt.Errorf("load task inputs error: %v", err)
 		}
+		imageRequest.SetInputs(inputs)
+	}
+	return request, parameter, "", nil
+}
+
+// transportReason 取出调用失败的原因
+func transportReason(err error) string {
+	var transportErr *TransportError
+	if errors.As(err, &transportErr) {
+		return transportErr.Reason
+	}
+	return metrics.ReasonWrite
+}
+
+// saveResult 解析模型的响应并保存结果，返回任务的最终状态
+func saveResult(ctx context.Context, m Model, task *model.Task, request PredictRequest, message []byte) Status {
+	_, phase := tracing.Tracer.Start(ctx, "call.decode")
+	artifacts, err := decodeArtifacts(request, message)
+	tracing.End(phase, err)
+	if err != nil {
+		// 更新任务状态为失败
+		failTask(ctx, task, m.Name, metrics.ReasonDecode, fmt.Sprintf("decode message error: %v", err))
+		return Fail
+	}
+	images, outputs := taskOutputs(artifacts)
+	// 更新任务状态为成功
+	persistCtx, phase := tracing.Tracer.Start(ctx, "call.persist")
+	err = taskRepository.SaveResult(persistCtx, task.ID, images, outputs)
+	tracing.End(phase, err)
+	if err != nil {
+		logging.FromContext(ctx).WithError(err).Error("update task error")
+		metrics.TaskFailures.WithLabelValues(m.Name, metrics.ReasonPersist).Inc()
+		return Fail
 	}
+	logging.FromContext(ctx).WithFields(logrus.Fields{"images": len(images), "outputs": len(outputs)}).Info("task succeeded")
+	return Success
 }
@@ -0,0 +1,182 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gorilla/websocket"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"net/http/httptest"
+	"severless-task-scheduler/tracing"
+	"strings"
+	"testing"
+)
+
+func TestScheduleTask(t *testing.T) {
+	var received A1111Request
+	m, calls := a1111Backend(
+		t, func(w http.ResponseWriter, r *http.Request) {
+			_ = json.NewDecoder(r.Body).Decode(&received)
+			_ = json.NewEncoder(w).Encode(A1111Response{Images: []string{testImage, testImage}})
+		},
+	)
+	setupTest(t, map[string]Model{"sd": m})
+
+	createTestTask(t, `{"model":"sd","prompt":"cat","rand_seed":42}`)
+	scheduleAndDrain(t)
+
+	if *calls != 1 || received.Prompt != "cat" || received.Seed != 42 {
+		t.Fatalf("got %d calls with prompt %q seed %d, want one call with the task parameters", *calls, received.Prompt, received.Seed)
+	}
+	detail := getTestTask(t, 1)
+	if detail.Status != int32(Success) || detail.Image1 == nil || detail.Image2 == nil {
+		t.Fatalf("got status %d, want success with two images", detail.Status)
+	}
+	if len(detail.Outputs) != 2 || detail.Outputs[0].Kind != OutputImage || detail.Outputs[0].MimeType != "image/png" {
+		t.Errorf("got outputs %+v, want two png images", detail.Outputs)
+	}
+}
+
+func TestScheduleTaskBackendError(t *testing.T) {
+	m, calls := a1111Backend(
+		t, func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "bad request", http.StatusBadRequest)
+		},
+	)
+	m.Retries = 2
+	setupTest(t, map[string]Model{"sd": m})
+
+	createTestTask(t, `{"model":"sd","prompt":"cat"}`)
+	scheduleAndDrain(t)
+
+	detail := getTestTask(t, 1)
+	if detail.Status != int32(Fail) || detail.Message == nil || !strings.Contains(*detail.Message, "400") {
+		t.Errorf("got status %d message %v, want failed with the backend status", detail.Status, detail.Message)
+	}
+	if *calls != 1 {
+		t.Errorf("got %d calls, want 4xx not to be retried", *calls)
+	}
+}
+
+func TestScheduleTaskEmptyResponse(t *testing.T) {
+	m, _ := a1111Backend(
+		t, func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"images":[]}`))
+		},
+	)
+	setupTest(t, map[string]Model{"sd": m})
+
+	createTestTask(t, `{"model":"sd","prompt":"cat"}`)
+	scheduleAndDrain(t)
+
+	detail := getTestTask(t, 1)
+	if detail.Status != int32(Fail) || detail.Message == nil || !strings.Contains(*detail.Message, errNoOutputs.Error()) {
+		t.Errorf("got status %d message %v, want failed without outputs", detail.Status, detail.Message)
+	}
+}
+
+func TestScheduleTaskNotConfigured(t *testing.T) {
+	setupTest(t, map[string]Model{})
+	cfg.Scheduler.Limit = 0
+
+	recorder, response := serve(t, ScheduleTask, httptest.NewRequest(http.MethodPost, "/api/schedule_task", nil))
+	if recorder.Code != http.StatusServiceUnavailable || response.Code != CodeUnavailable {
+		t.Errorf("got status %d code %v, want 503 %s", recorder.Code, response.Code, CodeUnavailable)
+	}
+}
+
+func TestCallWebsocket(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				connection, err := upgrader.Upgrade(w, r, nil)
+				if err != nil {
+					return
+				}
+				defer connection.Close()
+				for {
+					_, message, err := connection.ReadMessage()
+					if err != nil {
+						return
+					}
+					request := GradioRequest{}
+					_ = json.Unmarshal(message, &request)
+					images, _ := json.Marshal([]string{"data:image/png;base64," + testImage})
+					if request.Prompt != "cat" {
+						images = []byte(`"unexpected prompt"`)
+					}
+					_ = connection.WriteMessage(websocket.TextMessage, images)
+				}
+			},
+		),
+	)
+	t.Cleanup(server.Close)
+	r := setupTest(t, map[string]Model{"openjourney": {Api: "ws" + strings.TrimPrefix(server.URL, "http")}})
+
+	createTestTask(t, `{"model":"openjourney","prompt":"cat"}`)
+	task, err := r.Get(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("get task: %v", err)
+	}
+	m, _ := lookupModel("openjourney")
+	b, _ := lookupBackend("openjourney")
+	if status := call(context.Background(), m, b, task); status != Success {
+		t.Fatalf("got status %s, want success", status)
+	}
+	detail := getTestTask(t, 1)
+	if detail.Image1 == nil || len(detail.Outputs) != 1 || detail.Outputs[0].MimeType != "image/png" {
+		t.Errorf("got image1 %v outputs %+v, want one png image", detail.Image1, detail.Outputs)
+	}
+}
+
+func TestLookupAndTrackRetired(t *testing.T) {
+	m, _ := a1111Backend(t, nil)
+	setupTest(t, map[string]Model{"sd": m})
+
+	b, done, ok := lookupAndTrack("sd")
+	if !ok {
+		t.Fatal("backend not found")
+	}
+	retireBackend("sd", b)
+	if _, _, ok := lookupAndTrack("sd"); ok {
+		t.Error("retired backend is still dispatched")
+	}
+	select {
+	case <-b.stop:
+		t.Fatal("backend closed while a call is in flight")
+	default:
+	}
+	done()
+}
+
+func TestCallPropagatesTraceContext(t *testing.T) {
+	var traceParent string
+	m, _ := a1111Backend(
+		t, func(w http.ResponseWriter, r *http.Request) {
+			traceParent = r.Header.Get(tracing.TraceParentHeader)
+			_ = json.NewEncoder(w).Encode(A1111Response{Images: []string{testImage}})
+		},
+	)
+	r := setupTest(t, map[string]Model{"sd": m})
+
+	createTestTask(t, `{"model":"sd","prompt":"cat"}`)
+	task, err := r.Get(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("get task: %v", err)
+	}
+	traceID := trace.TraceID{1}
+	ctx := trace.ContextWithRemoteSpanContext(
+		context.Background(), trace.NewSpanContext(
+			trace.SpanContextConfig{TraceID: traceID, SpanID: trace.SpanID{1}, TraceFlags: trace.FlagsSampled},
+		),
+	)
+	m, _ = lookupModel("sd")
+	b, _ := lookupBackend("sd")
+	if status := call(ctx, m, b, task); status != Success {
+		t.Fatalf("got status %s, want success", status)
+	}
+	if !strings.Contains(traceParent, traceID.String()) {
+		t.Errorf("got traceparent %q, want trace %s", traceParent, traceID)
+	}
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"context"
+	"github.com/sirupsen/logrus"
+	"sync"
+	"time"
+)
+
+// inflight 记录正在执行的 call 协程
+var inflight sync.WaitGroup
+
+var scheduleNotify = make(chan struct{}, 1)
+
+// NotifySchedule 唤醒调度循环，不会阻塞
+func NotifySchedule() {
+	select {
+	case scheduleNotify <- struct{}{}:
+	default:
+	}
+}
+
+// RunScheduler 持续认领并派发任务，直到 ctx 被取消
+// 每隔 interval 轮询一次，创建任务时也会通过 NotifySchedule 立即唤醒
+func RunScheduler(ctx context.Context, interval time.Duration, limit int) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		dispatched, err := Schedule(ctx, limit)
+		if err != nil {
+			logrus.WithError(err).Error("schedule task error")
+		}
+		// 本轮已满额说明还有积压，直接进入下一轮
+		if dispatched >= limit {
+			select {
+			case <-ctx.Done():
+				return
+			default:
+				continue
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-scheduleNotify:
+		}
+	}
+}
+
+// Drain 等待所有正在执行的 call 协程结束，ctx 超时则返回 ctx 的错误
+func Drain(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		inflight.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
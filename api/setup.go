@@ -0,0 +1,63 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"severless-task-scheduler/config"
+	"severless-task-scheduler/db/api"
+	"severless-task-scheduler/db/driver"
+	"severless-task-scheduler/db/repository"
+	"severless-task-scheduler/logging"
+)
+
+// cfg 服务的配置，Setup 之前为空配置
+var cfg = &config.Config{}
+var db *gorm.DB
+var dbErr error
+var taskRepository repository.TaskRepository
+var modelRepository repository.ModelRepository
+
+// Setup 按配置打开数据库、加载模型并连接所有模型后端，cmd/server 启动时调用一次
+// 数据库暂时不可用时仍然返回 nil，由 readyz 报告数据库的状态
+func Setup(c *config.Config) error {
+	cfg = c
+	db, dbErr = driver.Open(
+		c.Database, &gorm.Config{
+			Logger: logging.NewGormLogger(c.Database.SlowQueryThreshold.Duration()),
+		},
+	)
+	taskRepository = repository.WithTracing(repository.NewGormTaskRepository(api.Use(db)))
+	modelRepository = repository.NewGormModelRepository(api.Use(db))
+
+	initial, err := initialModels(context.Background())
+	if err != nil {
+		return err
+	}
+	modelsMu.Lock()
+	models = initial
+	modelsMu.Unlock()
+	connect(initial)
+	return nil
+}
+
+// initialModels 启动时的模型，从数据库加载失败时先以空的模型集合启动，等待下一次刷新
+func initialModels(ctx context.Context) (map[string]Model, error) {
+	if cfg.Registry.Source == config.RegistrySourceDatabase {
+		registry, err := loadRegistry(ctx)
+		if err != nil {
+			logging.FromContext(ctx).WithError(err).Error("load model registry error")
+			return make(map[string]Model), nil
+		}
+		return registry, nil
+	}
+	if len(cfg.Models) == 0 {
+		return nil, errors.New("no model configured, set models in CONFIG_FILE or MODEL_CONFIG")
+	}
+	return cfg.Models, nil
+}
+
+// SetTaskRepository 替换任务存储，用于测试或本地开发
+func SetTaskRepository(r repository.TaskRepository) {
+	taskRepository = r
+}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"severless-task-scheduler/config"
+	"severless-task-scheduler/db/model"
+	"severless-task-scheduler/db/repository"
+	"testing"
+	"time"
+)
+
+// testImage 最小的 png 文件头，足以被识别为 image/png
+var testImage = base64.StdEncoding.EncodeToString([]byte("\x89PNG\r\n\x1a\n0000"))
+
+type testResponse struct {
+	Code    any             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// setupTest 使用内存存储和给定的模型，测试结束后移除模型的后端
+func setupTest(t *testing.T, models map[string]Model) repository.TaskRepository {
+	t.Helper()
+	cfg = &config.Config{
+		Server:    config.ServerConfig{IdempotencyKeyTTL: config.Duration(time.Hour)},
+		Scheduler: config.SchedulerConfig{Limit: 5},
+	}
+	r := repository.NewMemoryTaskRepository()
+	SetTaskRepository(r)
+	for name, m := range models {
+		models[name] = m.WithDefaults(name)
+	}
+	ApplyModels(models)
+	t.Cleanup(
+		func() {
+			ApplyModels(map[string]Model{})
+		},
+	)
+	return r
+}
+
+// a1111Backend 模拟 WebUI 的 txt2img 接口，handler 为 nil 时返回一张图片
+func a1111Backend(t *testing.T, handler http.HandlerFunc) (Model, *int) {
+	t.Helper()
+	calls := 0
+	if handler == nil {
+		handler = func(w http.ResponseWriter, r *http.Request) {
+			_ = json.NewEncoder(w).Encode(A1111Response{Images: []string{testImage}})
+		}
+	}
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				handler(w, r)
+			},
+		),
+	)
+	t.Cleanup(server.Close)
+	m := Model{Adapter: "a1111", Api: server.URL + "/sdapi/v1/txt2img", RetryBackoff: config.Duration(time.Millisecond)}
+	return m, &calls
+}
+
+func serve(t *testing.T, handler http.HandlerFunc, r *http.Request) (*httptest.ResponseRecorder, testResponse) {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	handler(recorder, r)
+	response := testResponse{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatalf("decode response %q: %v", recorder.Body.String(), err)
+	}
+	return recorder, response
+}
+
+func createTestTask(t *testing.T, body string) (*httptest.ResponseRecorder, testResponse) {
+	t.Helper()
+	return serve(t, CreateTask, httptest.NewRequest(http.MethodPost, "/api/create_task", bytes.NewBufferString(body)))
+}
+
+func getTestTask(t *testing.T, id int64) TaskDetail {
+	t.Helper()
+	query := url.Values{"id": {jsonNumber(id)}}
+	recorder, response := serve(t, GetTask, httptest.NewRequest(http.MethodGet, "/api/get_task?"+query.Encode(), nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("get task %d: status %d: %s", id, recorder.Code, response.Message)
+	}
+	detail := TaskDetail{Task: &model.Task{}}
+	if err := json.Unmarshal(response.Data, &detail); err != nil {
+		t.Fatalf("decode task: %v", err)
+	}
+	return detail
+}
+
+// scheduleAndDrain 调度一次并等待派发的调用结束
+func scheduleAndDrain(t *testing.T) {
+	t.Helper()
+	recorder, response := serve(t, ScheduleTask, httptest.NewRequest(http.MethodPost, "/api/schedule_task", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("schedule task: status %d: %s", recorder.Code, response.Message)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := Drain(ctx); err != nil {
+		t.Fatalf("drain: %v", err)
+	}
+}
+
+func jsonNumber(id int64) string {
+	marshal, _ := json.Marshal(id)
+	return string(marshal)
+}
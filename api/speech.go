@@ -0,0 +1,104 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"severless-task-scheduler/db/model"
+)
+
+const (
+	DefaultVoice         = "alloy"
+	DefaultSpeechFormat  = "mp3"
+	MaxSpeechInputLength = 4096
+	MaxSpeechVoiceLength = 64
+)
+
+// speechFormats 支持的音频格式
+var speechFormats = []string{"mp3", "opus", "aac", "flac", "wav"}
+
+// speechMimeTypes 音频格式对应的 MIME 类型
+var speechMimeTypes = map[string]string{
+	"mp3":  "audio/mpeg",
+	"opus": "audio/ogg",
+	"aac":  "audio/aac",
+	"flac": "audio/flac",
+	"wav":  "audio/wav",
+}
+
+// SpeechRequest OpenAI 兼容的 /v1/audio/speech 请求，任务参数中的 prompt 为要朗读的文本
+// 响应是二进制的音频，模型需要使用 http transport
+type SpeechRequest struct {
+	Model          string `json:"model"`
+	Input          string `json:"input"`
+	Voice          string `json:"voice"`
+	ResponseFormat string `json:"response_format"`
+}
+
+func (s *SpeechRequest) Json() []byte {
+	marshal, _ := json.Marshal(s)
+	return marshal
+}
+
+func (s *SpeechRequest) Parse(r io.Reader, task *model.Task) error {
+	all, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	parameter := TaskParameter{}
+	err = json.Unmarshal(all, &parameter)
+	if err != nil {
+		return err
+	}
+	s.Model = parameter.Model
+	s.Input = parameter.Prompt
+	s.Voice = DefaultVoice
+	s.ResponseFormat = DefaultSpeechFormat
+	if parameter.Voice != nil {
+		s.Voice = *parameter.Voice
+	}
+	if parameter.ResponseFormat != nil {
+		s.ResponseFormat = *parameter.ResponseFormat
+	}
+	return nil
+}
+
+func (s *SpeechRequest) Outputs() []string {
+	return []string{OutputAudio}
+}
+
+// DecodeArtifacts 整个响应即为一段音频
+func (s *SpeechRequest) DecodeArtifacts(message []byte) ([]Artifact, error) {
+	if len(message) == 0 {
+		return nil, errors.New("empty audio in response")
+	}
+	return []Artifact{{Kind: OutputAudio, MimeType: speechMimeTypes[s.ResponseFormat], Data: message}}, nil
+}
+
+func (s *SpeechRequest) Schema() *Schema {
+	return &Schema{
+		Type: "object",
+		Properties: map[string]*Property{
+			"model": {
+				Type: "string",
+			},
+			"prompt": {
+				Type:      "string",
+				MinLength: IntPtr(1),
+				MaxLength: IntPtr(MaxSpeechInputLength),
+			},
+			"voice": {
+				Type:      "string",
+				MinLength: IntPtr(1),
+				MaxLength: IntPtr(MaxSpeechVoiceLength),
+				Default:   DefaultVoice,
+			},
+			"response_format": {
+				Type:    "string",
+				Enum:    speechFormats,
+				Default: DefaultSpeechFormat,
+			},
+		},
+		Required: []string{"model", "prompt"},
+	}
+}
@@ -0,0 +1,203 @@
+package api
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"severless-task-scheduler/db/model"
+)
+
+const (
+	TaskTypeTxt2Img = "txt2img"
+	TaskTypeImg2Img = "img2img"
+	TaskTypeInpaint = "inpaint"
+
+	DefaultStrength = 0.75
+
+	// InputInitImage img2img 和 inpaint 的原图，InputMask inpaint 的蒙版，白色部分会被重新生成
+	InputInitImage = "init_image"
+	InputMask      = "mask"
+
+	MaxInputImageSize      = 8 * 1024 * 1024
+	MaxInputImageDimension = 2048
+	// MaxTaskRequestSize multipart 请求的大小上限，包含两张图片和参数
+	MaxTaskRequestSize = 2*MaxInputImageSize + 1024*1024
+)
+
+// inputContentTypes 允许上传的图片格式
+var inputContentTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+}
+
+// ImageRequest 支持 img2img 和 inpaint 的请求，call 会把任务的输入图片交给它
+type ImageRequest interface {
+	SetInputs(inputs []*model.TaskInput)
+}
+
+// imageTaskProperties 支持 img2img 和 inpaint 的请求额外接受的参数
+func imageTaskProperties() map[string]*Property {
+	return map[string]*Property{
+		"task_type": {
+			Type:    "string",
+			Enum:    []string{TaskTypeTxt2Img, TaskTypeImg2Img, TaskTypeInpaint},
+			Default: TaskTypeTxt2Img,
+		},
+		"strength": {
+			Type:    "number",
+			Minimum: IntPtr(0),
+			Maximum: IntPtr(1),
+			Default: DefaultStrength,
+		},
+	}
+}
+
+// readTaskRequest 读取创建任务的请求。multipart 请求的参数在 parameter 字段中，
+// 图片在 init_image 和 mask 字段中；其他请求的 body 即为参数
+func readTaskRequest(w http.ResponseWriter, r *http.Request) ([]byte, []*model.TaskInput, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		body, err := io.ReadAll(r.Body)
+		return body, nil, err
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, MaxTaskRequestSize)
+	err := r.ParseMultipartForm(MaxTaskRequestSize)
+	if err != nil {
+		if e := asError(err); e.Code == CodeTooLarge {
+			return nil, nil, e
+		}
+		return nil, nil, NewValidationError("invalid multipart form", FieldError{Field: "", Message: err.Error()})
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	fieldErrors := make([]FieldError, 0)
+	inputs := make([]*model.TaskInput, 0)
+	for name, files := range r.MultipartForm.File {
+		if name != InputInitImage && name != InputMask {
+			fieldErrors = append(fieldErrors, FieldError{Field: name, Message: "is not supported"})
+			continue
+		}
+		if len(files) != 1 {
+			fieldErrors = append(fieldErrors, FieldError{Field: name, Message: "must be a single file"})
+			continue
+		}
+		input, message := readInputImage(name, files[0])
+		if message != "" {
+			fieldErrors = append(fieldErrors, FieldError{Field: name, Message: message})
+			continue
+		}
+		inputs = append(inputs, input)
+	}
+	if len(fieldErrors) > 0 {
+		return nil, nil, NewValidationError("invalid parameter", fieldErrors...)
+	}
+	return []byte(r.FormValue("parameter")), inputs, nil
+}
+
+// readInputImage 读取上传的图片，按内容判断格式，返回的 message 不为空表示图片无效
+func readInputImage(name string, header *multipart.FileHeader) (*model.TaskInput, string) {
+	if header.Size > MaxInputImageSize {
+		return nil, fmt.Sprintf("must be at most %d bytes", MaxInputImageSize)
+	}
+	file, err := header.Open()
+	if err != nil {
+		return nil, fmt.Sprintf("read file error: %v", err)
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, MaxInputImageSize+1))
+	if err != nil {
+		return nil, fmt.Sprintf("read file error: %v", err)
+	}
+	if len(data) > MaxInputImageSize {
+		return nil, fmt.Sprintf("must be at most %d bytes", MaxInputImageSize)
+	}
+	contentType := http.DetectContentType(data)
+	if !inputContentTypes[contentType] {
+		return nil, "must be a png or jpeg image"
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Sprintf("invalid image: %v", err)
+	}
+	if config.Width > MaxInputImageDimension || config.Height > MaxInputImageDimension {
+		return nil, fmt.Sprintf("must be at most %dx%d pixels", MaxInputImageDimension, MaxInputImageDimension)
+	}
+	return &model.TaskInput{Name: name, ContentType: contentType, Data: data}, ""
+}
+
+// validateTaskInputs 检查上传的图片是否符合任务类型
+func validateTaskInputs(parameter *TaskParameter, inputs []*model.TaskInput) []FieldError {
+	fieldErrors := make([]FieldError, 0)
+	uploaded := make(map[string]*model.TaskInput, len(inputs))
+	for _, input := range inputs {
+		uploaded[input.Name] = input
+	}
+	taskType := TaskTypeTxt2Img
+	if parameter.TaskType != nil {
+		taskType = *parameter.TaskType
+	}
+	required := map[string]bool{}
+	switch taskType {
+	case TaskTypeImg2Img:
+		required[InputInitImage] = true
+	case TaskTypeInpaint:
+		required[InputInitImage] = true
+		required[InputMask] = true
+	default:
+		if parameter.Strength != nil {
+			fieldErrors = append(fieldErrors, FieldError{Field: "strength", Message: "is only supported for img2img and inpaint"})
+		}
+	}
+	for _, name := range []string{InputInitImage, InputMask} {
+		_, ok := uploaded[name]
+		switch {
+		case required[name] && !ok:
+			fieldErrors = append(fieldErrors, FieldError{Field: name, Message: fmt.Sprintf("is required for %s", taskType)})
+		case !required[name] && ok:
+			fieldErrors = append(fieldErrors, FieldError{Field: name, Message: fmt.Sprintf("is not supported for %s", taskType)})
+		}
+	}
+	initImage, mask := uploaded[InputInitImage], uploaded[InputMask]
+	if len(fieldErrors) == 0 && initImage != nil && mask != nil {
+		initConfig, _, _ := image.DecodeConfig(bytes.NewReader(initImage.Data))
+		maskConfig, _, _ := image.DecodeConfig(bytes.NewReader(mask.Data))
+		if initConfig.Width != maskConfig.Width || initConfig.Height != maskConfig.Height {
+			fieldErrors = append(fieldErrors, FieldError{Field: InputMask, Message: "must have the same size as init_image"})
+		}
+	}
+	return fieldErrors
+}
+
+// taskTypeOf 任务参数中的 task_type，未指定时为 txt2img
+func taskTypeOf(parameter []byte) string {
+	content := struct {
+		TaskType string `json:"task_type"`
+	}{}
+	if err := json.Unmarshal(parameter, &content); err != nil || content.TaskType == "" {
+		return TaskTypeTxt2Img
+	}
+	return content.TaskType
+}
+
+// dataURL 把输入图片编码为 data url
+func dataURL(input *model.TaskInput) string {
+	return fmt.Sprintf("data:%s;base64,%s", input.ContentType, base64.StdEncoding.EncodeToString(input.Data))
+}
+
+// findInput 按名称查找输入图片
+func findInput(inputs []*model.TaskInput, name string) *model.TaskInput {
+	for _, input := range inputs {
+		if input.Name == name {
+			return input
+		}
+	}
+	return nil
+}
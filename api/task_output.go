@@ -0,0 +1,161 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"severless-task-scheduler/db/model"
+	"severless-task-scheduler/db/repository"
+	"severless-task-scheduler/media"
+)
+
+const (
+	OutputImage = "image"
+	OutputText  = "text"
+	OutputAudio = "audio"
+	OutputJSON  = "json"
+
+	// StorageRefInline 输出内容保存在 t_task_output.data 中
+	StorageRefInline = "t_task_output.data"
+	// storageRefImage 输出内容保存在 t_task 的第 n 个图片字段中
+	storageRefImage = "t_task.image%d"
+)
+
+// Artifact 模型产生的一个输出。图片的 Data 为 base64 字符串，与图片字段的保存格式一致；
+// 内容保存在后端时 Data 为空，URL 为内容的地址
+type Artifact struct {
+	Kind     string
+	MimeType string
+	Data     []byte
+	URL      string
+}
+
+// errNoOutputs 模型的响应中没有任何输出，如 {"images":[]}，不能当作成功的结果保存
+var errNoOutputs = errors.New("no outputs in response")
+
+// OutputRequest 声明请求会产生的输出类型，未实现的请求只产生图片
+type OutputRequest interface {
+	Outputs() []string
+}
+
+// ArtifactDecoder 产生非图片输出的请求自行从响应中取出输出
+type ArtifactDecoder interface {
+	DecodeArtifacts(message []byte) ([]Artifact, error)
+}
+
+// OutputInfo 对外展示的任务输出，内容通过 get_task_output 获取
+type OutputInfo struct {
+	Index      int32  `json:"index"`
+	Kind       string `json:"kind"`
+	MimeType   string `json:"mime_type"`
+	Size       int64  `json:"size"`
+	StorageRef string `json:"storage_ref"`
+}
+
+// outputKinds 请求会产生的输出类型
+func outputKinds(request PredictRequest) []string {
+	if outputRequest, ok := request.(OutputRequest); ok {
+		return outputRequest.Outputs()
+	}
+	return []string{OutputImage}
+}
+
+// decodeArtifacts 从模型的响应中取出输出，只产生图片的请求沿用 ResponseDecoder 或图片数组
+func decodeArtifacts(request PredictRequest, message []byte) ([]Artifact, error) {
+	if decoder, ok := request.(ArtifactDecoder); ok {
+		artifacts, err := decoder.DecodeArtifacts(message)
+		if err != nil {
+			return nil, err
+		}
+		if len(artifacts) == 0 {
+			return nil, errNoOutputs
+		}
+		declared := make(map[string]bool)
+		for _, kind := range outputKinds(request) {
+			declared[kind] = true
+		}
+		for i, artifact := range artifacts {
+			if !declared[artifact.Kind] {
+				return nil, fmt.Errorf("output %d kind %q is not declared by the adapter", i, artifact.Kind)
+			}
+		}
+		return artifacts, nil
+	}
+	images := make([]string, 0)
+	var err error
+	if decoder, ok := request.(ResponseDecoder); ok {
+		images, err = decoder.Decode(message)
+	} else {
+		err = json.Unmarshal(message, &images)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(images) == 0 {
+		return nil, errNoOutputs
+	}
+	artifacts := make([]Artifact, 0, len(images))
+	for _, image := range images {
+		artifacts = append(artifacts, Artifact{Kind: OutputImage, MimeType: media.ImageMimeType(image), Data: []byte(image)})
+	}
+	return artifacts, nil
+}
+
+// taskOutputs 把输出拆分为写入图片字段的图片和 t_task_output 的记录，超出 MaxImages 的图片会被丢弃
+func taskOutputs(artifacts []Artifact) ([][]byte, []*model.TaskOutput) {
+	images := make([][]byte, 0)
+	outputs := make([]*model.TaskOutput, 0, len(artifacts))
+	for _, artifact := range artifacts {
+		output := &model.TaskOutput{
+			Seq:      int32(len(outputs)),
+			Kind:     artifact.Kind,
+			MimeType: artifact.MimeType,
+			Size:     int64(len(artifact.Data)),
+		}
+		switch {
+		case artifact.URL != "":
+			output.StorageRef = artifact.URL
+		case artifact.Kind == OutputImage:
+			if len(images) >= repository.MaxImages {
+				continue
+			}
+			images = append(images, artifact.Data)
+			output.StorageRef = fmt.Sprintf(storageRefImage, len(images))
+			if data, err := media.DecodeImage(artifact.Data); err == nil {
+				output.Size = int64(len(data))
+			}
+		default:
+			data := artifact.Data
+			output.StorageRef = StorageRefInline
+			output.Data = &data
+		}
+		outputs = append(outputs, output)
+	}
+	return images, outputs
+}
+
+// imageSlot 保存在图片字段中的输出对应的字段序号，从 1 开始
+func imageSlot(storageRef string) (int, bool) {
+	var slot int
+	if _, err := fmt.Sscanf(storageRef, storageRefImage, &slot); err != nil {
+		return 0, false
+	}
+	return slot, slot >= 1 && slot <= repository.MaxImages
+}
+
+// outputInfos 把 t_task_output 的记录转换为对外展示的输出
+func outputInfos(outputs []*model.TaskOutput) []OutputInfo {
+	infos := make([]OutputInfo, 0, len(outputs))
+	for _, output := range outputs {
+		infos = append(
+			infos, OutputInfo{
+				Index:      output.Seq,
+				Kind:       output.Kind,
+				MimeType:   output.MimeType,
+				Size:       output.Size,
+				StorageRef: output.StorageRef,
+			},
+		)
+	}
+	return infos
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"github.com/gorilla/websocket"
+	"severless-task-scheduler/metrics"
+	"severless-task-scheduler/tracing"
+)
+
+// Transport 把请求发送给模型后端并返回响应，由 Model.Transport 选择实现
+type Transport interface {
+	// Call 发送一次请求并等待响应，失败时返回 *TransportError
+	Call(ctx context.Context, request PredictRequest) ([]byte, error)
+	// Connected 后端当前是否可用
+	Connected() bool
+}
+
+// JobTransport 异步后端，提交后立即返回后端的任务 ID，之后轮询结果
+type JobTransport interface {
+	Submit(ctx context.Context, request PredictRequest) (string, error)
+	Poll(ctx context.Context, jobID string) (*JobStatus, error)
+}
+
+// JobStatus 异步任务的状态，Done 为 false 表示仍在执行
+type JobStatus struct {
+	Done bool
+	// Output 成功时的结果，按 adapter 解析
+	Output []byte
+	// Error 失败原因，为空表示成功
+	Error string
+}
+
+// EndpointRequest 按参数使用不同接口的请求，返回实际请求的地址，只对 http 生效
+type EndpointRequest interface {
+	Endpoint(api string) string
+}
+
+// TransportError 调用失败的原因，Reason 为 metrics 中的失败原因，Status 为 http 后端返回的非 2xx 状态码
+type TransportError struct {
+	Reason string
+	Status int
+	Err    error
+}
+
+func (e *TransportError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *TransportError) Unwrap() error {
+	return e.Err
+}
+
+func transportError(reason string, format string, args ...any) *TransportError {
+	return &TransportError{Reason: reason, Err: fmt.Errorf(format, args...)}
+}
+
+// websocketTransport 通过后端的长连接发送请求，同一时间只有一个调用使用连接
+type websocketTransport struct {
+	b *backend
+}
+
+func (t *websocketTransport) Call(ctx context.Context, request PredictRequest) ([]byte, error) {
+	m := t.b.model
+	s, err := t.b.begin()
+	if err != nil {
+		return nil, transportError(metrics.ReasonNoConnection, "get connection error, model: %s", m.Name)
+	}
+	defer s.end()
+	_, phase := tracing.Tracer.Start(ctx, "call.write")
+	err = s.write(request.Json())
+	tracing.End(phase, err)
+	if err != nil {
+		return nil, transportError(metrics.ReasonWrite, "write message error: %v", err)
+	}
+	_, phase = tracing.Tracer.Start(ctx, "call.wait")
+	messageType, message, err := s.receive(m.ReadTimeout.Duration())
+	tracing.End(phase, err)
+	if err != nil {
+		return nil, transportError(metrics.ReasonRead, "read message error: %v", err)
+	}
+	if messageType != websocket.TextMessage {
+		return nil, transportError(metrics.ReasonDecode, "decode message error: unexpected message type: %d", messageType)
+	}
+	return message, nil
+}
+
+func (t *websocketTransport) Connected() bool {
+	return t.b.getConnection() != nil
+}
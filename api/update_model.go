@@ -0,0 +1,49 @@
+package api
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"net/http"
+	"severless-task-scheduler/logging"
+	"severless-task-scheduler/tracing"
+)
+
+// UpdateModel 按名称替换模型的配置，未指定 enabled 时保持原来的状态
+func UpdateModel(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracing.StartHandler(r, "UpdateModel")
+	defer span.End()
+
+	parameter, err := readModelParameter(r)
+	if err != nil {
+		responseError(w, err)
+		return
+	}
+	existing, err := modelRepository.Get(ctx, parameter.Name)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		responseError(w, NewNotFoundError("model %s not found", parameter.Name))
+		return
+	}
+	if err != nil {
+		responseError(w, err)
+		return
+	}
+
+	record := parameter.record()
+	record.ID = existing.ID
+	record.CreatedAt = existing.CreatedAt
+	record.Enabled = existing.Enabled
+	if parameter.Enabled != nil {
+		record.Enabled = *parameter.Enabled
+	}
+	err = modelRepository.Update(ctx, record)
+	if err != nil {
+		logging.FromContext(ctx).WithError(err).Error("update model error")
+		responseError(w, err)
+		return
+	}
+	logging.FromContext(ctx).WithField(logging.FieldModel, record.Name).Info("model updated")
+	if _, err = ReloadRegistry(ctx); err != nil {
+		logging.FromContext(ctx).WithError(err).Error("reload models error")
+	}
+	responseData(w, record)
+}
@@ -0,0 +1,119 @@
+// claimbench 向数据库写入大量任务后测量 TaskRepository.Claim 的耗时
+//
+//	DATABASE_DRIVER=sqlite DATABASE_DSN=/tmp/bench.db go run ./cmd/claimbench -rows 1000000
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"math/rand"
+	"severless-task-scheduler/config"
+	"severless-task-scheduler/db/api"
+	"severless-task-scheduler/db/driver"
+	"severless-task-scheduler/db/migration"
+	"severless-task-scheduler/db/model"
+	"severless-task-scheduler/db/repository"
+	"sort"
+	"time"
+)
+
+func main() {
+	rows := flag.Int("rows", 1000000, "number of tasks to seed")
+	pendingRatio := flag.Float64("pending", 0.05, "ratio of seeded tasks left in init status")
+	imageSize := flag.Int("image-size", 0, "bytes of image1 for finished tasks")
+	batchSize := flag.Int("batch", 1000, "insert batch size")
+	limit := flag.Int("limit", 10, "claim limit")
+	iterations := flag.Int("iterations", 200, "number of claims to measure")
+	skipSeed := flag.Bool("skip-seed", false, "reuse existing rows")
+	flag.Parse()
+	if *iterations <= 0 || *limit <= 0 {
+		logrus.Fatal("iterations and limit must be positive")
+	}
+
+	cfg, err := config.Get()
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	db, err := driver.Open(cfg.Database, &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		logrus.Fatalf("open database error: %v", err)
+	}
+	migrator, err := migration.New(db)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	if _, err = migrator.Up(0); err != nil {
+		logrus.Fatal(err)
+	}
+
+	if !*skipSeed {
+		start := time.Now()
+		if err = seed(db, *rows, *batchSize, *pendingRatio, *imageSize); err != nil {
+			logrus.Fatalf("seed error: %v", err)
+		}
+		fmt.Printf("seeded %d rows in %s\n", *rows, time.Since(start))
+	}
+
+	repo := repository.NewGormTaskRepository(api.Use(db))
+	durations := make([]time.Duration, 0, *iterations)
+	claimed := 0
+	for i := 0; i < *iterations; i++ {
+		start := time.Now()
+		tasks, err := repo.Claim(context.Background(), *limit, nil)
+		if err != nil {
+			logrus.Fatalf("claim error: %v", err)
+		}
+		durations = append(durations, time.Since(start))
+		claimed += len(tasks)
+		if len(tasks) == 0 {
+			break
+		}
+	}
+	sort.Slice(
+		durations, func(i, j int) bool {
+			return durations[i] < durations[j]
+		},
+	)
+	fmt.Printf(
+		"claims=%d tasks=%d limit=%d min=%s p50=%s p99=%s max=%s\n",
+		len(durations), claimed, *limit,
+		durations[0], percentile(durations, 0.5), percentile(durations, 0.99), durations[len(durations)-1],
+	)
+}
+
+func seed(db *gorm.DB, rows int, batchSize int, pendingRatio float64, imageSize int) error {
+	models := []string{"openjourney", "anything", "waifu", "nerverendDream"}
+	image := make([]byte, imageSize)
+	batch := make([]*model.Task, 0, batchSize)
+	for i := 0; i < rows; i++ {
+		task := &model.Task{
+			Parameter: `{"model":"openjourney","prompt":"benchmark"}`,
+			Model:     models[i%len(models)],
+			UserID:    int64(rand.Intn(10000)),
+			Status:    int32(model.StatusSuccess),
+			Priority:  int32(rand.Intn(3)),
+		}
+		if rand.Float64() < pendingRatio {
+			task.Status = int32(model.StatusInit)
+		} else if imageSize > 0 {
+			task.Image1 = &image
+		}
+		batch = append(batch, task)
+		if len(batch) == batchSize || i == rows-1 {
+			if err := db.Create(&batch).Error; err != nil {
+				return err
+			}
+			batch = batch[:0]
+		}
+	}
+	return nil
+}
+
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	index := int(float64(len(sorted)-1) * p)
+	return sorted[index]
+}
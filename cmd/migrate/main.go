@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"os"
+	"severless-task-scheduler/config"
+	"severless-task-scheduler/db/driver"
+	"severless-task-scheduler/db/migration"
+	"severless-task-scheduler/logging"
+	"strconv"
+)
+
+const usage = `usage: migrate <command> [steps]
+
+commands:
+  up [steps]     apply pending migrations, all of them if steps is omitted
+  down [steps]   roll back applied migrations, one if steps is omitted
+  status         list migrations and whether they have been applied
+
+The database is read from CONFIG_FILE, DATABASE_DRIVER and DATABASE_DSN override it.`
+
+func main() {
+	cfg, err := config.Get()
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	logging.Setup(cfg.Log)
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+	command := os.Args[1]
+	steps := 0
+	if command == "down" {
+		steps = 1
+	}
+	if len(os.Args) > 2 {
+		n, err := strconv.Atoi(os.Args[2])
+		if err != nil || n < 0 {
+			logrus.Fatalf("invalid steps: %s", os.Args[2])
+		}
+		steps = n
+	}
+
+	db, err := driver.Open(cfg.Database, &gorm.Config{Logger: logging.NewGormLogger(cfg.Database.SlowQueryThreshold.Duration())})
+	if err != nil {
+		logrus.Fatalf("open database error: %v", err)
+	}
+	migrator, err := migration.New(db)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+
+	switch command {
+	case "up":
+		executed, err := migrator.Up(steps)
+		for _, m := range executed {
+			fmt.Printf("applied %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if len(executed) == 0 {
+			fmt.Println("no pending migrations")
+		}
+	case "down":
+		executed, err := migrator.Down(steps)
+		for _, m := range executed {
+			fmt.Printf("rolled back %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if len(executed) == 0 {
+			fmt.Println("no applied migrations")
+		}
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%-40s %s\n", status.Version, status.Name, appliedAt)
+		}
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
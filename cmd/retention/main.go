@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"os"
+	"os/signal"
+	"severless-task-scheduler/config"
+	"severless-task-scheduler/db/api"
+	"severless-task-scheduler/db/driver"
+	"severless-task-scheduler/db/repository"
+	"severless-task-scheduler/logging"
+	"severless-task-scheduler/retention"
+	"syscall"
+)
+
+func main() {
+	cfg, err := config.Get()
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	logging.Setup(cfg.Log)
+	retentionConfig := retention.ConfigFrom(cfg.Retention)
+	if len(retentionConfig.Policies) == 0 {
+		logrus.Fatal("no retention policy configured")
+	}
+	db, err := driver.Open(cfg.Database, &gorm.Config{Logger: logging.NewGormLogger(cfg.Database.SlowQueryThreshold.Duration())})
+	if err != nil {
+		logrus.Fatalf("open database error: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	runner := retention.NewRunner(repository.NewGormTaskRepository(api.Use(db)), retentionConfig)
+	report, err := runner.Run(ctx)
+	output, _ := json.Marshal(report)
+	logrus.Infof("retention report: %s", output)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+}
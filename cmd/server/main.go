@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"os"
+	"os/signal"
+	"severless-task-scheduler/api"
+	"severless-task-scheduler/config"
+	"severless-task-scheduler/logging"
+	"severless-task-scheduler/tracing"
+	"syscall"
+	"time"
+)
+
+const DefaultShutdownTimeout = 30 * time.Second
+
+func main() {
+	cfg := config.MustGet()
+	logging.Setup(cfg.Log)
+	if err := api.Setup(cfg); err != nil {
+		logrus.Fatal(err)
+	}
+	handlerTimeout := cfg.Server.HandlerTimeout.Duration()
+
+	server := &http.Server{
+		Addr: cfg.Server.ListenAddr,
+		Handler: Chain(
+			routes(cfg.Server.AdminToken),
+			RequestID,
+			Logging,
+			Recovery,
+			CORS(cfg.Server.CORSAllowedOrigins),
+			Timeout(handlerTimeout),
+		),
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       handlerTimeout,
+		WriteTimeout:      handlerTimeout + 5*time.Second,
+		IdleTimeout:       2 * time.Minute,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	shutdownTracing, err := tracing.Init(ctx)
+	if err != nil {
+		logrus.Fatalf("init tracing error: %v", err)
+	}
+
+	schedulerDone := make(chan struct{})
+	if cfg.Scheduler.Enabled {
+		go func() {
+			defer close(schedulerDone)
+			runScheduler(ctx, cfg.Scheduler)
+		}()
+	} else {
+		close(schedulerDone)
+	}
+
+	switch {
+	case cfg.Registry.Source == config.RegistrySourceDatabase && cfg.Registry.RefreshInterval > 0:
+		go api.WatchRegistry(ctx, cfg.Registry.RefreshInterval.Duration())
+	case config.Path() != "" && cfg.Server.ConfigWatchInterval > 0:
+		go api.WatchConfig(ctx, config.Path(), cfg.Server.ConfigWatchInterval.Duration())
+	}
+
+	go func() {
+		logrus.Infof("server listening on %s", cfg.Server.ListenAddr)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logrus.Fatalf("listen error: %v", err)
+		}
+	}()
+
+	<-ctx.Done()
+	logrus.Info("shutting down server")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), DefaultShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		logrus.Errorf("shutdown error: %v", err)
+	}
+
+	<-schedulerDone
+	logrus.Info("draining in-flight tasks")
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), drainTimeout())
+	defer cancelDrain()
+	if err := api.Drain(drainCtx); err != nil {
+		logrus.Errorf("drain error: %v", err)
+	}
+	api.Close()
+	if err := shutdownTracing(drainCtx); err != nil {
+		logrus.Errorf("shutdown tracing error: %v", err)
+	}
+}
+
+// runScheduler 在进程内运行调度循环，直到 ctx 被取消
+func runScheduler(ctx context.Context, scheduler config.SchedulerConfig) {
+	interval := scheduler.PollInterval.Duration()
+	logrus.Infof("scheduler started, poll interval %s, limit %d", interval, scheduler.Limit)
+	api.RunScheduler(ctx, interval, scheduler.Limit)
+	logrus.Info("scheduler stopped")
+}
+
+// drainTimeout 等待执行中的任务的最长时间，取所有模型中最长的读取超时
+func drainTimeout() time.Duration {
+	timeout := config.DefaultModelReadTimeout
+	for _, m := range api.Models() {
+		if m.ReadTimeout.Duration() > timeout {
+			timeout = m.ReadTimeout.Duration()
+		}
+	}
+	return timeout
+}
+
+func routes(adminToken string) http.Handler {
+	admin := RequireAdmin(adminToken)
+	mux := http.NewServeMux()
+	mux.Handle("/api/create_task", AllowMethods(api.CreateTask, http.MethodPost))
+	mux.Handle("/api/get_task", AllowMethods(api.GetTask, http.MethodGet))
+	mux.Handle("/api/get_task_output", AllowMethods(api.GetTaskOutput, http.MethodGet))
+	mux.Handle("/api/schedule_task", AllowMethods(api.ScheduleTask, http.MethodGet, http.MethodPost))
+	mux.Handle("/api/get_model_schema", AllowMethods(api.GetModelSchema, http.MethodGet))
+	mux.Handle("/api/list_models", AllowMethods(api.ListModels, http.MethodGet))
+	mux.Handle("/api/admin/reload_models", admin(AllowMethods(api.ReloadModels, http.MethodPost)))
+	mux.Handle("/api/admin/create_model", admin(AllowMethods(api.CreateModel, http.MethodPost)))
+	mux.Handle("/api/admin/update_model", admin(AllowMethods(api.UpdateModel, http.MethodPost)))
+	mux.Handle("/api/admin/disable_model", admin(AllowMethods(api.DisableModel, http.MethodPost)))
+	mux.Handle("/api/admin/run_retention", admin(AllowMethods(api.RunRetention, http.MethodPost)))
+	mux.Handle("/metrics", AllowMethods(api.Metrics, http.MethodGet))
+	mux.Handle("/healthz", AllowMethods(api.Healthz, http.MethodGet, http.MethodHead))
+	mux.Handle("/readyz", AllowMethods(api.Readyz, http.MethodGet, http.MethodHead))
+	return mux
+}
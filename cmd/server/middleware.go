@@ -0,0 +1,181 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"runtime/debug"
+	"severless-task-scheduler/api"
+	"severless-task-scheduler/logging"
+	"strings"
+	"time"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+type Middleware func(http.Handler) http.Handler
+
+// Chain 按顺序组合中间件，第一个中间件位于最外层
+func Chain(handler http.Handler, middlewares ...Middleware) http.Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
+
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			requestID := r.Header.Get(RequestIDHeader)
+			if requestID == "" {
+				requestID = newRequestID()
+			}
+			w.Header().Set(RequestIDHeader, requestID)
+			next.ServeHTTP(w, r.WithContext(logging.WithRequestID(r.Context(), requestID)))
+		},
+	)
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func Logging(next http.Handler) http.Handler {
+	return http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(recorder, r)
+			logging.FromContext(r.Context()).WithFields(
+				logrus.Fields{
+					"method":   r.Method,
+					"path":     r.URL.Path,
+					"status":   recorder.status,
+					"duration": time.Since(start).String(),
+				},
+			).Info("handle request")
+		},
+	)
+}
+
+func Recovery(next http.Handler) http.Handler {
+	return http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				if err := recover(); err != nil {
+					logging.FromContext(r.Context()).WithField("stack", string(debug.Stack())).
+						Errorf("panic: %v", err)
+					writeError(w, http.StatusInternalServerError, "internal_error", "internal server error")
+				}
+			}()
+			next.ServeHTTP(w, r)
+		},
+	)
+}
+
+// CORS 允许 allowedOrigins 中的来源跨域访问，"*" 表示允许所有来源
+func CORS(allowedOrigins []string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				origin := r.Header.Get("Origin")
+				if origin != "" && originAllowed(allowedOrigins, origin) {
+					w.Header().Set("Access-Control-Allow-Origin", origin)
+					w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+					w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type, "+RequestIDHeader+", "+api.IdempotencyKeyHeader)
+					w.Header().Set("Access-Control-Expose-Headers", RequestIDHeader+", "+api.IdempotentReplayedHeader)
+					w.Header().Add("Vary", "Origin")
+				}
+				if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+				next.ServeHTTP(w, r)
+			},
+		)
+	}
+}
+
+func originAllowed(allowedOrigins []string, origin string) bool {
+	for _, allowed := range allowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+func Timeout(timeout time.Duration) Middleware {
+	return func(next http.Handler) http.Handler {
+		body, _ := json.Marshal(
+			map[string]any{
+				"code":    "unavailable",
+				"message": "request timeout",
+			},
+		)
+		return http.TimeoutHandler(next, timeout, string(body))
+	}
+}
+
+// RequireAdmin 要求请求携带 Authorization: Bearer <token>，token 为空时拒绝所有请求
+func RequireAdmin(token string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				if token == "" {
+					writeError(w, http.StatusUnauthorized, "unauthorized", "admin api is disabled")
+					return
+				}
+				provided := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+				if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+					writeError(w, http.StatusUnauthorized, "unauthorized", "invalid admin token")
+					return
+				}
+				next.ServeHTTP(w, r)
+			},
+		)
+	}
+}
+
+// AllowMethods 限制处理函数可接受的请求方法
+func AllowMethods(handler http.HandlerFunc, methods ...string) http.Handler {
+	return http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			for _, method := range methods {
+				if r.Method == method {
+					handler(w, r)
+					return
+				}
+			}
+			w.Header().Set("Allow", strings.Join(methods, ", "))
+			writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+		},
+	)
+}
+
+func writeError(w http.ResponseWriter, status int, code string, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	responseBody := map[string]any{
+		"code":    code,
+		"message": message,
+	}
+	responseBytes, _ := json.Marshal(responseBody)
+	w.Write(responseBytes)
+}
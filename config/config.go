@@ -0,0 +1,334 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultListenAddr         = ":8080"
+	DefaultHandlerTimeout     = 30 * time.Second
+	DefaultPollInterval       = 5 * time.Second
+	DefaultSlowQueryThreshold = 200 * time.Millisecond
+	DefaultRetentionBatchSize = 500
+	DefaultIdempotencyKeyTTL  = 24 * time.Hour
+
+	RegistrySourceConfig   = "config"
+	RegistrySourceDatabase = "database"
+
+	DefaultRegistryRefreshInterval = 30 * time.Second
+
+	TransportWebsocket = "websocket"
+	TransportHTTP      = "http"
+
+	AdapterGradio     = "gradio"
+	AdapterA1111      = "a1111"
+	AdapterCompletion = "completion"
+	AdapterSpeech     = "speech"
+
+	// NgrokSkipBrowserWarningHeader 让 ngrok 免费域名跳过浏览器警告页，模型可以把它设为空字符串来取消
+	NgrokSkipBrowserWarningHeader = "ngrok-skip-browser-warning"
+
+	DefaultModelReadTimeout     = 15 * time.Minute
+	DefaultModelHeartbeatPeriod = 10 * time.Second
+	DefaultModelMaxReadSize     = 1024 * 1024
+	DefaultModelRetryBackoff    = time.Second
+	DefaultModelPollInterval    = 5 * time.Second
+	DefaultModelPollTimeout     = time.Hour
+)
+
+// AdapterTransports 每种 adapter 可以使用的 transport
+// WebUI 和 OpenAI 兼容的接口都是 REST 接口，speech 的响应是二进制音频，只能使用 http
+var AdapterTransports = map[string][]string{
+	AdapterGradio:     {TransportWebsocket, TransportHTTP},
+	AdapterA1111:      {TransportHTTP},
+	AdapterCompletion: {TransportHTTP},
+	AdapterSpeech:     {TransportHTTP},
+}
+
+// Config 服务的全部配置，从 CONFIG_FILE 指定的 YAML/JSON 文件加载后再用环境变量覆盖
+type Config struct {
+	Database  DatabaseConfig         `yaml:"database" json:"database"`
+	Server    ServerConfig           `yaml:"server" json:"server"`
+	Scheduler SchedulerConfig        `yaml:"scheduler" json:"scheduler"`
+	Log       LogConfig              `yaml:"log" json:"log"`
+	Retention RetentionConfig        `yaml:"retention" json:"retention"`
+	Registry  RegistryConfig         `yaml:"registry" json:"registry"`
+	Models    map[string]ModelConfig `yaml:"models" json:"models"`
+}
+
+type DatabaseConfig struct {
+	Driver             string   `yaml:"driver" json:"driver"`
+	DSN                string   `yaml:"dsn" json:"dsn"`
+	SlowQueryThreshold Duration `yaml:"slow_query_threshold" json:"slow_query_threshold"`
+}
+
+type ServerConfig struct {
+	ListenAddr         string   `yaml:"listen_addr" json:"listen_addr"`
+	HandlerTimeout     Duration `yaml:"handler_timeout" json:"handler_timeout"`
+	CORSAllowedOrigins []string `yaml:"cors_allowed_origins" json:"cors_allowed_origins"`
+	// AdminToken 管理接口的 Bearer token，为空时管理接口不可用
+	AdminToken string `yaml:"admin_token" json:"-"`
+	// ConfigWatchInterval 检查配置文件是否修改的间隔，0 表示不检查
+	ConfigWatchInterval Duration `yaml:"config_watch_interval" json:"config_watch_interval"`
+	// IdempotencyKeyTTL Idempotency-Key 的有效期，过期后同一个键可以再次创建任务
+	IdempotencyKeyTTL Duration `yaml:"idempotency_key_ttl" json:"idempotency_key_ttl"`
+}
+
+type SchedulerConfig struct {
+	Enabled      bool     `yaml:"enabled" json:"enabled"`
+	Limit        int      `yaml:"limit" json:"limit"`
+	PollInterval Duration `yaml:"poll_interval" json:"poll_interval"`
+}
+
+type LogConfig struct {
+	Level  string `yaml:"level" json:"level"`
+	Format string `yaml:"format" json:"format"`
+}
+
+type RetentionConfig struct {
+	SuccessImageTTL  Duration `yaml:"success_image_ttl" json:"success_image_ttl"`
+	SuccessDeleteTTL Duration `yaml:"success_delete_ttl" json:"success_delete_ttl"`
+	FailImageTTL     Duration `yaml:"fail_image_ttl" json:"fail_image_ttl"`
+	FailDeleteTTL    Duration `yaml:"fail_delete_ttl" json:"fail_delete_ttl"`
+	ArchiveDir       string   `yaml:"archive_dir" json:"archive_dir"`
+	BatchSize        int      `yaml:"batch_size" json:"batch_size"`
+	MaxBatches       int      `yaml:"max_batches" json:"max_batches"`
+}
+
+// RegistryConfig 模型的来源，config 使用配置中的 models，database 使用 t_model 表
+type RegistryConfig struct {
+	Source string `yaml:"source" json:"source"`
+	// RefreshInterval 从数据库刷新模型的间隔，只对 database 生效
+	RefreshInterval Duration `yaml:"refresh_interval" json:"refresh_interval"`
+}
+
+// ModelConfig 一个模型后端的配置，json 字段名与 MODEL_CONFIG 保持兼容
+type ModelConfig struct {
+	Name        string `yaml:"name" json:"name"`
+	DisplayName string `yaml:"display_name" json:"display_name"`
+	// Adapter 请求格式，为空时按模型名称选择
+	Adapter string `yaml:"adapter" json:"adapter,omitempty"`
+	Api     string `yaml:"api" json:"api"`
+	// Transport 为 websocket 或 http，为空时按 Api 的协议选择
+	Transport string `yaml:"transport" json:"transport,omitempty"`
+	// Retries http 请求失败后的重试次数，只重试网络错误、429 和 5xx
+	Retries      int      `yaml:"retries" json:"retries,omitempty"`
+	RetryBackoff Duration `yaml:"retry_backoff" json:"retry_backoff,omitempty"`
+	// Version 模型版本，修改后之前缓存的结果不再被复用
+	Version string `yaml:"version" json:"version,omitempty"`
+	// Cache 是否复用相同请求的成功结果，只对固定随机种子等结果确定的请求生效
+	Cache bool `yaml:"cache" json:"cache,omitempty"`
+	// Concurrency 同时派发给模型的任务数上限，0 表示不限制
+	Concurrency int `yaml:"concurrency" json:"concurrency,omitempty"`
+	// Headers 连接时附加的请求头，值可以是 env: 或 file: 引用，值为空的请求头不会发送
+	Headers         map[string]string `yaml:"headers" json:"headers,omitempty"`
+	Auth            AuthConfig        `yaml:"auth" json:"auth,omitempty"`
+	TLS             TLSConfig         `yaml:"tls" json:"tls,omitempty"`
+	Poll            PollConfig        `yaml:"poll" json:"poll,omitempty"`
+	ReadTimeout     Duration          `yaml:"read_timeout" json:"read_timeout,omitempty"`
+	HeartbeatPeriod Duration          `yaml:"heartbeat_period" json:"heartbeat_period,omitempty"`
+	MaxReadSize     int64             `yaml:"max_read_size" json:"max_read_size,omitempty"`
+	// Defaults 覆盖请求参数的默认值，键为参数名
+	Defaults map[string]any `yaml:"defaults" json:"defaults,omitempty"`
+	// Limits 覆盖请求参数的取值范围，键为参数名
+	Limits map[string]Limit `yaml:"limits" json:"limits,omitempty"`
+}
+
+// AuthConfig 连接模型后端的凭据，BearerToken 与 Username/Password 只能选择一种
+type AuthConfig struct {
+	BearerToken Secret `yaml:"bearer_token" json:"bearer_token,omitempty"`
+	Username    string `yaml:"username" json:"username,omitempty"`
+	Password    Secret `yaml:"password" json:"password,omitempty"`
+}
+
+// TLSConfig wss 连接的 TLS 配置，CAFile 为 PEM 格式的 CA 证书，CertFile 和 KeyFile 用于 mTLS
+type TLSConfig struct {
+	CAFile   string `yaml:"ca_file" json:"ca_file,omitempty"`
+	CertFile string `yaml:"cert_file" json:"cert_file,omitempty"`
+	KeyFile  string `yaml:"key_file" json:"key_file,omitempty"`
+}
+
+// PollConfig 异步后端的轮询配置，只对 http 生效。配置 StatusApi 后，向 Api 提交请求只返回任务 ID，
+// 调度时再通过 StatusApi 查询结果，不需要一直占用连接
+//
+// 提交的响应为 {"id": ...}，查询的响应为 {"status": ..., "output": ..., "error": ...}，
+// status 为 succeeded/success/completed 时 output 按 adapter 解析为结果，为 failed/error/canceled/cancelled 时任务失败
+type PollConfig struct {
+	// StatusApi 查询任务状态的地址，{id} 会被替换为后端返回的任务 ID
+	StatusApi string   `yaml:"status_api" json:"status_api,omitempty"`
+	Interval  Duration `yaml:"interval" json:"interval,omitempty"`
+	// Timeout 提交后超过该时间仍未完成则任务失败
+	Timeout Duration `yaml:"timeout" json:"timeout,omitempty"`
+}
+
+// Async 是否为提交后轮询结果的异步后端
+func (p PollConfig) Async() bool {
+	return p.StatusApi != ""
+}
+
+type Limit struct {
+	Minimum *int `yaml:"minimum" json:"minimum,omitempty"`
+	Maximum *int `yaml:"maximum" json:"maximum,omitempty"`
+}
+
+var (
+	loadOnce sync.Once
+	loadMu   sync.RWMutex
+	loaded   *Config
+	loadErr  error
+)
+
+// Path 配置文件的路径，为空表示只使用环境变量
+func Path() string {
+	return os.Getenv("CONFIG_FILE")
+}
+
+// Get 加载并缓存配置，进程内只加载一次，Reload 成功后返回新的配置
+func Get() (*Config, error) {
+	loadOnce.Do(
+		func() {
+			c, err := Load(Path())
+			loadMu.Lock()
+			loaded, loadErr = c, err
+			loadMu.Unlock()
+		},
+	)
+	loadMu.RLock()
+	defer loadMu.RUnlock()
+	return loaded, loadErr
+}
+
+// Reload 重新加载配置，失败时保留之前的配置
+func Reload() (*Config, error) {
+	c, err := Load(Path())
+	if err != nil {
+		return nil, err
+	}
+	loadOnce.Do(func() {})
+	loadMu.Lock()
+	defer loadMu.Unlock()
+	loaded, loadErr = c, nil
+	return c, nil
+}
+
+// MustGet 与 Get 相同，配置无效时 panic
+func MustGet() *Config {
+	c, err := Get()
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// Load 从文件加载配置，path 为空时只使用环境变量，最后填充默认值并校验
+func Load(path string) (*Config, error) {
+	c := &Config{}
+	if path != "" {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read config file: %v", err)
+		}
+		if strings.EqualFold(filepath.Ext(path), ".json") {
+			err = json.Unmarshal(content, c)
+		} else {
+			err = yaml.Unmarshal(content, c)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse config file %s: %v", path, err)
+		}
+	}
+	if err := c.applyEnv(); err != nil {
+		return nil, err
+	}
+	c.applyDefaults()
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Config) applyDefaults() {
+	if c.Server.ListenAddr == "" {
+		c.Server.ListenAddr = DefaultListenAddr
+	}
+	if c.Server.HandlerTimeout == 0 {
+		c.Server.HandlerTimeout = Duration(DefaultHandlerTimeout)
+	}
+	if c.Server.IdempotencyKeyTTL == 0 {
+		c.Server.IdempotencyKeyTTL = Duration(DefaultIdempotencyKeyTTL)
+	}
+	if len(c.Server.CORSAllowedOrigins) == 0 {
+		c.Server.CORSAllowedOrigins = []string{"*"}
+	}
+	if c.Scheduler.PollInterval == 0 {
+		c.Scheduler.PollInterval = Duration(DefaultPollInterval)
+	}
+	if c.Database.SlowQueryThreshold == 0 {
+		c.Database.SlowQueryThreshold = Duration(DefaultSlowQueryThreshold)
+	}
+	if c.Log.Level == "" {
+		c.Log.Level = "info"
+	}
+	if c.Log.Format == "" {
+		c.Log.Format = "json"
+	}
+	if c.Retention.BatchSize == 0 {
+		c.Retention.BatchSize = DefaultRetentionBatchSize
+	}
+	if c.Registry.Source == "" {
+		c.Registry.Source = RegistrySourceConfig
+	}
+	if c.Registry.RefreshInterval == 0 {
+		c.Registry.RefreshInterval = Duration(DefaultRegistryRefreshInterval)
+	}
+	for name, m := range c.Models {
+		c.Models[name] = m.WithDefaults(name)
+	}
+}
+
+// WithDefaults 填充未配置的字段，name 为模型在配置中的键
+func (m ModelConfig) WithDefaults(name string) ModelConfig {
+	if m.Name == "" {
+		m.Name = name
+	}
+	if _, ok := m.Headers[NgrokSkipBrowserWarningHeader]; !ok {
+		headers := map[string]string{NgrokSkipBrowserWarningHeader: "true"}
+		for key, value := range m.Headers {
+			headers[key] = value
+		}
+		m.Headers = headers
+	}
+	if m.Transport == "" {
+		m.Transport = TransportWebsocket
+		if u, err := url.Parse(m.Api); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+			m.Transport = TransportHTTP
+		}
+	}
+	if m.RetryBackoff == 0 {
+		m.RetryBackoff = Duration(DefaultModelRetryBackoff)
+	}
+	if m.ReadTimeout == 0 {
+		m.ReadTimeout = Duration(DefaultModelReadTimeout)
+	}
+	if m.HeartbeatPeriod == 0 {
+		m.HeartbeatPeriod = Duration(DefaultModelHeartbeatPeriod)
+	}
+	if m.MaxReadSize == 0 {
+		m.MaxReadSize = DefaultModelMaxReadSize
+	}
+	if m.Poll.Async() && m.Poll.Interval == 0 {
+		m.Poll.Interval = Duration(DefaultModelPollInterval)
+	}
+	if m.Poll.Async() && m.Poll.Timeout == 0 {
+		m.Poll.Timeout = Duration(DefaultModelPollTimeout)
+	}
+	return m
+}
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// applyEnv 用环境变量覆盖文件中的配置，变量名沿用此前各处读取的名称
+func (c *Config) applyEnv() error {
+	errs := make([]string, 0)
+	str := func(key string, target *string) {
+		if value, ok := os.LookupEnv(key); ok && value != "" {
+			*target = value
+		}
+	}
+	integer := func(key string, target *int) {
+		if value, ok := os.LookupEnv(key); ok && value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s is not a number: %s", key, value))
+				return
+			}
+			*target = parsed
+		}
+	}
+	duration := func(key string, target *Duration) {
+		if value, ok := os.LookupEnv(key); ok && value != "" {
+			if err := target.parse(value); err != nil {
+				errs = append(errs, fmt.Sprintf("%s is invalid: %v", key, err))
+			}
+		}
+	}
+
+	str("DATABASE_DRIVER", &c.Database.Driver)
+	str("DATABASE_DSN", &c.Database.DSN)
+	duration("DB_SLOW_QUERY_THRESHOLD", &c.Database.SlowQueryThreshold)
+
+	str("LISTEN_ADDR", &c.Server.ListenAddr)
+	duration("HANDLER_TIMEOUT", &c.Server.HandlerTimeout)
+	if value := os.Getenv("CORS_ALLOWED_ORIGINS"); value != "" {
+		c.Server.CORSAllowedOrigins = strings.Split(value, ",")
+	}
+	str("ADMIN_TOKEN", &c.Server.AdminToken)
+	duration("CONFIG_WATCH_INTERVAL", &c.Server.ConfigWatchInterval)
+	duration("IDEMPOTENCY_KEY_TTL", &c.Server.IdempotencyKeyTTL)
+
+	if value := os.Getenv("SCHEDULER_ENABLED"); value != "" {
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("SCHEDULER_ENABLED is not a boolean: %s", value))
+		}
+		c.Scheduler.Enabled = enabled
+	}
+	integer("SCHEDULE_TASK_LIMIT", &c.Scheduler.Limit)
+	duration("SCHEDULER_POLL_INTERVAL", &c.Scheduler.PollInterval)
+
+	str("LOG_LEVEL", &c.Log.Level)
+	str("LOG_FORMAT", &c.Log.Format)
+
+	duration("RETENTION_SUCCESS_IMAGE_TTL", &c.Retention.SuccessImageTTL)
+	duration("RETENTION_SUCCESS_DELETE_TTL", &c.Retention.SuccessDeleteTTL)
+	duration("RETENTION_FAIL_IMAGE_TTL", &c.Retention.FailImageTTL)
+	duration("RETENTION_FAIL_DELETE_TTL", &c.Retention.FailDeleteTTL)
+	str("RETENTION_ARCHIVE_DIR", &c.Retention.ArchiveDir)
+	integer("RETENTION_BATCH_SIZE", &c.Retention.BatchSize)
+	integer("RETENTION_MAX_BATCHES", &c.Retention.MaxBatches)
+
+	str("MODEL_REGISTRY", &c.Registry.Source)
+	duration("MODEL_REGISTRY_REFRESH_INTERVAL", &c.Registry.RefreshInterval)
+
+	// MODEL_CONFIG 整体替换文件中的模型配置
+	if value := os.Getenv("MODEL_CONFIG"); value != "" {
+		models := make(map[string]ModelConfig)
+		if err := json.Unmarshal([]byte(value), &models); err != nil {
+			errs = append(errs, fmt.Sprintf("MODEL_CONFIG is not valid json: %v", err))
+		} else {
+			c.Models = models
+		}
+	}
+
+	if len(errs) > 0 {
+		return &ValidationError{Problems: errs}
+	}
+	return nil
+}
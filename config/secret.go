@@ -0,0 +1,44 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	secretEnvPrefix  = "env:"
+	secretFilePrefix = "file:"
+)
+
+// Secret 敏感配置的引用，env:NAME 读取环境变量，file:/path 读取文件内容并去掉首尾空白
+// 其他值按原样使用，只适合不敏感的配置
+type Secret string
+
+// IsReference 是否为 env: 或 file: 引用
+func (s Secret) IsReference() bool {
+	return strings.HasPrefix(string(s), secretEnvPrefix) || strings.HasPrefix(string(s), secretFilePrefix)
+}
+
+// Resolve 读取引用的值，每次调用都会重新读取，以便轮换密钥后重新连接时生效
+func (s Secret) Resolve() (string, error) {
+	value := string(s)
+	switch {
+	case strings.HasPrefix(value, secretEnvPrefix):
+		name := strings.TrimPrefix(value, secretEnvPrefix)
+		resolved, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		return resolved, nil
+	case strings.HasPrefix(value, secretFilePrefix):
+		path := strings.TrimPrefix(value, secretFilePrefix)
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("read secret file: %v", err)
+		}
+		return strings.TrimSpace(string(content)), nil
+	default:
+		return value, nil
+	}
+}
@@ -0,0 +1,213 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// ValidationError 汇总所有配置问题，便于一次修正
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid config:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+func (c *Config) Validate() error {
+	problems := make([]string, 0)
+	add := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	switch c.Database.Driver {
+	case "", "mysql", "postgres", "sqlite":
+	default:
+		add("database.driver %q is not supported, use mysql, postgres or sqlite", c.Database.Driver)
+	}
+	if c.Server.HandlerTimeout < 0 {
+		add("server.handler_timeout must not be negative")
+	}
+	if c.Server.ConfigWatchInterval < 0 {
+		add("server.config_watch_interval must not be negative")
+	}
+	if c.Server.IdempotencyKeyTTL < 0 {
+		add("server.idempotency_key_ttl must not be negative")
+	}
+	if c.Scheduler.Limit < 0 {
+		add("scheduler.limit must not be negative")
+	}
+	if c.Scheduler.Enabled && c.Scheduler.Limit == 0 {
+		add("scheduler.limit (SCHEDULE_TASK_LIMIT) is required when the scheduler is enabled")
+	}
+	if c.Scheduler.PollInterval <= 0 {
+		add("scheduler.poll_interval must be positive")
+	}
+	switch strings.ToLower(c.Log.Level) {
+	case "trace", "debug", "info", "warn", "warning", "error", "fatal", "panic":
+	default:
+		add("log.level %q is not supported", c.Log.Level)
+	}
+	switch strings.ToLower(c.Log.Format) {
+	case "json", "text":
+	default:
+		add("log.format %q is not supported, use json or text", c.Log.Format)
+	}
+	switch c.Registry.Source {
+	case RegistrySourceConfig, RegistrySourceDatabase:
+	default:
+		add("registry.source %q is not supported, use %s or %s", c.Registry.Source, RegistrySourceConfig, RegistrySourceDatabase)
+	}
+	if c.Registry.RefreshInterval < 0 {
+		add("registry.refresh_interval must not be negative")
+	}
+	if c.Retention.BatchSize <= 0 {
+		add("retention.batch_size must be positive")
+	}
+	if c.Retention.MaxBatches < 0 {
+		add("retention.max_batches must not be negative")
+	}
+
+	names := make([]string, 0, len(c.Models))
+	for name := range c.Models {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		m := c.Models[name]
+		if problem := m.ValidateApi(); problem != "" {
+			add("models.%s.%s", name, problem)
+		}
+		if problem := m.ValidateAdapter(); problem != "" {
+			add("models.%s.%s", name, problem)
+		}
+		if m.Retries < 0 {
+			add("models.%s.retries must not be negative", name)
+		}
+		if m.ReadTimeout < 0 {
+			add("models.%s.read_timeout must not be negative", name)
+		}
+		if m.HeartbeatPeriod < 0 {
+			add("models.%s.heartbeat_period must not be negative", name)
+		}
+		if m.Concurrency < 0 {
+			add("models.%s.concurrency must not be negative", name)
+		}
+		if m.MaxReadSize < 0 {
+			add("models.%s.max_read_size must not be negative", name)
+		}
+		for _, problem := range m.Auth.Validate() {
+			add("models.%s.auth.%s", name, problem)
+		}
+		if (m.TLS.CertFile == "") != (m.TLS.KeyFile == "") {
+			add("models.%s.tls cert_file and key_file must be set together", name)
+		}
+		for _, problem := range m.ValidatePoll() {
+			add("models.%s.poll.%s", name, problem)
+		}
+		for parameter, limit := range m.Limits {
+			if limit.Minimum != nil && limit.Maximum != nil && *limit.Minimum > *limit.Maximum {
+				add("models.%s.limits.%s minimum is greater than maximum", name, parameter)
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// Validate 检查凭据配置，密钥必须使用 env: 或 file: 引用，避免明文出现在配置中
+func (a AuthConfig) Validate() []string {
+	problems := make([]string, 0)
+	if a.BearerToken != "" && (a.Username != "" || a.Password != "") {
+		problems = append(problems, "bearer_token and username/password cannot be used together")
+	}
+	if a.BearerToken != "" && !a.BearerToken.IsReference() {
+		problems = append(problems, "bearer_token must be an env: or file: reference")
+	}
+	if a.Password != "" && !a.Password.IsReference() {
+		problems = append(problems, "password must be an env: or file: reference")
+	}
+	if a.Username == "" && a.Password != "" {
+		problems = append(problems, "username is required when password is set")
+	}
+	return problems
+}
+
+// schemeProblem 检查 Api 的协议是否与 Transport 匹配
+func (m ModelConfig) schemeProblem(scheme string) string {
+	switch m.Transport {
+	case TransportWebsocket:
+		if scheme != "ws" && scheme != "wss" {
+			return "api scheme must be ws or wss for websocket transport"
+		}
+	case TransportHTTP:
+		if scheme != "http" && scheme != "https" {
+			return "api scheme must be http or https for http transport"
+		}
+	default:
+		return fmt.Sprintf("transport %q is not supported, use %s or %s", m.Transport, TransportWebsocket, TransportHTTP)
+	}
+	return ""
+}
+
+// ValidateApi 检查 Api 是否为有效的地址且与 Transport 匹配，m 需要先填充默认值
+func (m ModelConfig) ValidateApi() string {
+	u, err := url.Parse(m.Api)
+	if m.Api == "" || err != nil || u.Host == "" {
+		return "api must be a valid url"
+	}
+	return m.schemeProblem(u.Scheme)
+}
+
+// ValidateAdapter 检查 Adapter 是否受支持且可以使用 Transport，m 需要先填充默认值
+// Adapter 为空时按模型名称选择请求格式，不在这里检查
+func (m ModelConfig) ValidateAdapter() string {
+	if m.Adapter == "" {
+		return ""
+	}
+	transports, ok := AdapterTransports[m.Adapter]
+	if !ok {
+		names := make([]string, 0, len(AdapterTransports))
+		for name := range AdapterTransports {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Sprintf("adapter %q is not supported, use one of %s", m.Adapter, strings.Join(names, ", "))
+	}
+	for _, transport := range transports {
+		if transport == m.Transport {
+			return ""
+		}
+	}
+	return fmt.Sprintf("adapter %s requires %s transport", m.Adapter, strings.Join(transports, " or "))
+}
+
+// ValidatePoll 检查异步后端的轮询配置，m 需要先填充默认值
+func (m ModelConfig) ValidatePoll() []string {
+	problems := make([]string, 0)
+	if !m.Poll.Async() {
+		return problems
+	}
+	if m.Transport != TransportHTTP {
+		problems = append(problems, "status_api requires http transport")
+	}
+	u, err := url.Parse(m.Poll.StatusApi)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		problems = append(problems, "status_api must be a valid http or https url")
+	}
+	if !strings.Contains(m.Poll.StatusApi, "{id}") {
+		problems = append(problems, "status_api must contain {id}")
+	}
+	if m.Poll.Interval < 0 {
+		problems = append(problems, "interval must not be negative")
+	}
+	if m.Poll.Timeout < 0 {
+		problems = append(problems, "timeout must not be negative")
+	}
+	return problems
+}
@@ -0,0 +1,37 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateAdapter(t *testing.T) {
+	tests := []struct {
+		name    string
+		model   ModelConfig
+		problem string
+	}{
+		{"legacy model", ModelConfig{Api: "ws://localhost/ws"}, ""},
+		{"gradio over websocket", ModelConfig{Adapter: AdapterGradio, Api: "ws://localhost/ws"}, ""},
+		{"gradio over http", ModelConfig{Adapter: AdapterGradio, Api: "http://localhost/predict"}, ""},
+		{"a1111 over http", ModelConfig{Adapter: AdapterA1111, Api: "http://localhost/sdapi/v1/txt2img"}, ""},
+		{"unknown adapter", ModelConfig{Adapter: "gradoi", Api: "ws://localhost/ws"}, `adapter "gradoi" is not supported`},
+		{"speech over websocket", ModelConfig{Adapter: AdapterSpeech, Api: "ws://localhost/ws"}, "adapter speech requires http transport"},
+		{"a1111 over websocket", ModelConfig{Adapter: AdapterA1111, Api: "wss://localhost/ws"}, "adapter a1111 requires http transport"},
+	}
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				c := &Config{Models: map[string]ModelConfig{"m": test.model}}
+				c.applyDefaults()
+				err := c.Validate()
+				switch {
+				case test.problem == "" && err != nil:
+					t.Errorf("got %v, want valid", err)
+				case test.problem != "" && (err == nil || !strings.Contains(err.Error(), "models.m."+test.problem)):
+					t.Errorf("got %v, want %q", err, test.problem)
+				}
+			},
+		)
+	}
+}
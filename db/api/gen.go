@@ -17,23 +17,35 @@ import (
 
 func Use(db *gorm.DB, opts ...gen.DOOption) *Query {
 	return &Query{
-		db:   db,
-		Task: newTask(db, opts...),
+		db:              db,
+		IdempotencyKey:  newIdempotencyKey(db, opts...),
+		RegisteredModel: newRegisteredModel(db, opts...),
+		Task:            newTask(db, opts...),
+		TaskInput:       newTaskInput(db, opts...),
+		TaskOutput:      newTaskOutput(db, opts...),
 	}
 }
 
 type Query struct {
 	db *gorm.DB
 
-	Task task
+	IdempotencyKey  idempotencyKey
+	RegisteredModel registeredModel
+	Task            task
+	TaskInput       taskInput
+	TaskOutput      taskOutput
 }
 
 func (q *Query) Available() bool { return q.db != nil }
 
 func (q *Query) clone(db *gorm.DB) *Query {
 	return &Query{
-		db:   db,
-		Task: q.Task.clone(db),
+		db:              db,
+		IdempotencyKey:  q.IdempotencyKey.clone(db),
+		RegisteredModel: q.RegisteredModel.clone(db),
+		Task:            q.Task.clone(db),
+		TaskInput:       q.TaskInput.clone(db),
+		TaskOutput:      q.TaskOutput.clone(db),
 	}
 }
 
@@ -47,18 +59,30 @@ func (q *Query) WriteDB() *Query {
 
 func (q *Query) ReplaceDB(db *gorm.DB) *Query {
 	return &Query{
-		db:   db,
-		Task: q.Task.replaceDB(db),
+		db:              db,
+		IdempotencyKey:  q.IdempotencyKey.replaceDB(db),
+		RegisteredModel: q.RegisteredModel.replaceDB(db),
+		Task:            q.Task.replaceDB(db),
+		TaskInput:       q.TaskInput.replaceDB(db),
+		TaskOutput:      q.TaskOutput.replaceDB(db),
 	}
 }
 
 type queryCtx struct {
-	Task *taskDo
+	IdempotencyKey  *idempotencyKeyDo
+	RegisteredModel *registeredModelDo
+	Task            *taskDo
+	TaskInput       *taskInputDo
+	TaskOutput      *taskOutputDo
 }
 
 func (q *Query) WithContext(ctx context.Context) *queryCtx {
 	return &queryCtx{
-		Task: q.Task.WithContext(ctx),
+		IdempotencyKey:  q.IdempotencyKey.WithContext(ctx),
+		RegisteredModel: q.RegisteredModel.WithContext(ctx),
+		Task:            q.Task.WithContext(ctx),
+		TaskInput:       q.TaskInput.WithContext(ctx),
+		TaskOutput:      q.TaskOutput.WithContext(ctx),
 	}
 }
 
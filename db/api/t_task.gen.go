@@ -38,6 +38,15 @@ func newTask(db *gorm.DB, opts ...gen.DOOption) task {
 	_task.UserID = field.NewInt64(tableName, "user_id")
 	_task.Status = field.NewInt32(tableName, "status")
 	_task.Message = field.NewString(tableName, "message")
+	_task.Model = field.NewString(tableName, "model")
+	_task.Priority = field.NewInt32(tableName, "priority")
+	_task.NextRunAt = field.NewTime(tableName, "next_run_at")
+	_task.TraceParent = field.NewString(tableName, "trace_parent")
+	_task.Attempts = field.NewInt32(tableName, "attempts")
+	_task.ExternalJobID = field.NewString(tableName, "external_job_id")
+	_task.JobSubmittedAt = field.NewTime(tableName, "job_submitted_at")
+	_task.CacheKey = field.NewString(tableName, "cache_key")
+	_task.CachedFrom = field.NewInt64(tableName, "cached_from")
 
 	_task.fillFieldMap()
 
@@ -47,18 +56,27 @@ func newTask(db *gorm.DB, opts ...gen.DOOption) task {
 type task struct {
 	taskDo
 
-	ALL       field.Asterisk
-	ID        field.Int64
-	Parameter field.String
-	Image1    field.Bytes
-	Image2    field.Bytes
-	Image3    field.Bytes
-	Image4    field.Bytes
-	CreatedAt field.Time
-	UpdatedAt field.Time
-	UserID    field.Int64
-	Status    field.Int32
-	Message   field.String
+	ALL            field.Asterisk
+	ID             field.Int64
+	Parameter      field.String
+	Image1         field.Bytes
+	Image2         field.Bytes
+	Image3         field.Bytes
+	Image4         field.Bytes
+	CreatedAt      field.Time
+	UpdatedAt      field.Time
+	UserID         field.Int64
+	Status         field.Int32
+	Message        field.String
+	Model          field.String
+	Priority       field.Int32
+	NextRunAt      field.Time
+	TraceParent    field.String
+	Attempts       field.Int32
+	ExternalJobID  field.String
+	JobSubmittedAt field.Time
+	CacheKey       field.String
+	CachedFrom     field.Int64
 
 	fieldMap map[string]field.Expr
 }
@@ -86,6 +104,15 @@ func (t *task) updateTableName(table string) *task {
 	t.UserID = field.NewInt64(table, "user_id")
 	t.Status = field.NewInt32(table, "status")
 	t.Message = field.NewString(table, "message")
+	t.Model = field.NewString(table, "model")
+	t.Priority = field.NewInt32(table, "priority")
+	t.NextRunAt = field.NewTime(table, "next_run_at")
+	t.TraceParent = field.NewString(table, "trace_parent")
+	t.Attempts = field.NewInt32(table, "attempts")
+	t.ExternalJobID = field.NewString(table, "external_job_id")
+	t.JobSubmittedAt = field.NewTime(table, "job_submitted_at")
+	t.CacheKey = field.NewString(table, "cache_key")
+	t.CachedFrom = field.NewInt64(table, "cached_from")
 
 	t.fillFieldMap()
 
@@ -102,7 +129,7 @@ func (t *task) GetFieldByName(fieldName string) (field.OrderExpr, bool) {
 }
 
 func (t *task) fillFieldMap() {
-	t.fieldMap = make(map[string]field.Expr, 11)
+	t.fieldMap = make(map[string]field.Expr, 20)
 	t.fieldMap["id"] = t.ID
 	t.fieldMap["parameter"] = t.Parameter
 	t.fieldMap["image1"] = t.Image1
@@ -114,6 +141,15 @@ func (t *task) fillFieldMap() {
 	t.fieldMap["user_id"] = t.UserID
 	t.fieldMap["status"] = t.Status
 	t.fieldMap["message"] = t.Message
+	t.fieldMap["model"] = t.Model
+	t.fieldMap["priority"] = t.Priority
+	t.fieldMap["next_run_at"] = t.NextRunAt
+	t.fieldMap["trace_parent"] = t.TraceParent
+	t.fieldMap["attempts"] = t.Attempts
+	t.fieldMap["external_job_id"] = t.ExternalJobID
+	t.fieldMap["job_submitted_at"] = t.JobSubmittedAt
+	t.fieldMap["cache_key"] = t.CacheKey
+	t.fieldMap["cached_from"] = t.CachedFrom
 }
 
 func (t task) clone(db *gorm.DB) task {
@@ -0,0 +1,53 @@
+package driver
+
+import (
+	"fmt"
+	"github.com/glebarez/sqlite"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"severless-task-scheduler/config"
+)
+
+const (
+	MySQL    = "mysql"
+	Postgres = "postgres"
+	SQLite   = "sqlite"
+)
+
+// Dialector 根据驱动名称创建 gorm 方言，driver 为空时使用 MySQL
+func Dialector(driver string, dsn string) (gorm.Dialector, error) {
+	switch driver {
+	case "", MySQL:
+		return mysql.Open(dsn), nil
+	case Postgres:
+		return postgres.Open(dsn), nil
+	case SQLite:
+		return sqlite.Open(dsn), nil
+	default:
+		return nil, fmt.Errorf("unsupported database driver: %s", driver)
+	}
+}
+
+// Open 按数据库配置打开连接
+// 与 gorm.Open 一样，连接失败时仍会返回可用于构造查询的 *gorm.DB
+func Open(database config.DatabaseConfig, gormConfig *gorm.Config) (*gorm.DB, error) {
+	driver := database.Driver
+	dialector, err := Dialector(driver, database.DSN)
+	if err != nil {
+		return nil, err
+	}
+	db, err := gorm.Open(dialector, gormConfig)
+	if err != nil {
+		return db, err
+	}
+	if driver == SQLite {
+		// SQLite 只允许一个写连接，多连接并发写入会返回 database is locked
+		sqlDB, err := db.DB()
+		if err != nil {
+			return nil, err
+		}
+		sqlDB.SetMaxOpenConns(1)
+	}
+	return db, nil
+}
@@ -1,10 +1,10 @@
 package main
 
 import (
-	"gorm.io/driver/mysql"
 	"gorm.io/gen"
 	"gorm.io/gorm"
-	"os"
+	"severless-task-scheduler/config"
+	"severless-task-scheduler/db/driver"
 )
 
 func main() {
@@ -27,13 +27,24 @@ func main() {
 
 	// reuse the database connection in Project or create a connection here
 	// if you want to use GenerateModel/GenerateModelAs, UseDB is necessray or it will panic
-	db, _ := gorm.Open(mysql.Open(os.Getenv("DATABASE_DSN")))
+	cfg, err := config.Get()
+	if err != nil {
+		panic(err)
+	}
+	db, err := driver.Open(cfg.Database, &gorm.Config{})
+	if err != nil {
+		panic(err)
+	}
 	g.UseDB(db)
 
 	// apply basic crud api on structs or table models which is specified by table name with function
 	// GenerateModel/GenerateModelAs. And generator will generate table models' code when calling Excute.
 	g.ApplyBasic(
 		g.GenerateModelAs("t_task", "Task"),
+		g.GenerateModelAs("t_model", "RegisteredModel"),
+		g.GenerateModelAs("t_task_input", "TaskInput"),
+		g.GenerateModelAs("t_task_output", "TaskOutput"),
+		g.GenerateModelAs("t_idempotency_key", "IdempotencyKey"),
 	)
 
 	// execute the action of code generation
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS t_task
+(
+    id         BIGINT AUTO_INCREMENT PRIMARY KEY,
+    parameter  TEXT                               NOT NULL,
+    image1     LONGBLOB                           NULL,
+    image2     LONGBLOB                           NULL,
+    image3     LONGBLOB                           NULL,
+    image4     LONGBLOB                           NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP NOT NULL ON UPDATE CURRENT_TIMESTAMP,
+    user_id    BIGINT                             NOT NULL,
+    status     INT      DEFAULT 1                 NOT NULL,
+    message    TEXT                               NULL
+);
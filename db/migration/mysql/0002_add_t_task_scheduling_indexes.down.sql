@@ -0,0 +1,5 @@
+DROP INDEX idx_t_task_user_id_created_at ON t_task;
+DROP INDEX idx_t_task_status_next_run_at ON t_task;
+ALTER TABLE t_task DROP COLUMN next_run_at;
+ALTER TABLE t_task DROP COLUMN priority;
+ALTER TABLE t_task DROP COLUMN model;
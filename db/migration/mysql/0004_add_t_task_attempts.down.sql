@@ -0,0 +1 @@
+ALTER TABLE t_task DROP COLUMN attempts;
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS t_model
+(
+    id                 BIGINT AUTO_INCREMENT PRIMARY KEY,
+    name               VARCHAR(64)                          NOT NULL,
+    display_name       VARCHAR(128) DEFAULT ''              NOT NULL,
+    adapter            VARCHAR(32)  DEFAULT ''              NOT NULL,
+    api                VARCHAR(512)                         NOT NULL,
+    enabled            TINYINT(1)   DEFAULT 1               NOT NULL,
+    concurrency        INT          DEFAULT 0               NOT NULL,
+    default_parameters TEXT                                 NULL,
+    headers            TEXT                                 NULL,
+    created_at         DATETIME     DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at         DATETIME     DEFAULT CURRENT_TIMESTAMP NOT NULL ON UPDATE CURRENT_TIMESTAMP,
+    CONSTRAINT uk_t_model_name UNIQUE (name)
+);
@@ -0,0 +1,2 @@
+ALTER TABLE t_model DROP COLUMN tls;
+ALTER TABLE t_model DROP COLUMN auth;
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS t_task_output
+(
+    id          BIGINT AUTO_INCREMENT PRIMARY KEY,
+    task_id     BIGINT                               NOT NULL,
+    seq         INT                                  NOT NULL,
+    kind        VARCHAR(16)                          NOT NULL,
+    mime_type   VARCHAR(128)                         NOT NULL,
+    storage_ref VARCHAR(512)                         NOT NULL,
+    size        BIGINT   DEFAULT 0                   NOT NULL,
+    data        LONGBLOB                             NULL,
+    created_at  DATETIME DEFAULT CURRENT_TIMESTAMP   NOT NULL,
+    CONSTRAINT uk_t_task_output_task_id_seq UNIQUE (task_id, seq)
+);
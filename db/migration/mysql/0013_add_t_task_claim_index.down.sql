@@ -0,0 +1 @@
+DROP INDEX idx_t_task_status_priority_id ON t_task;
@@ -0,0 +1 @@
+CREATE INDEX idx_t_task_status_priority_id ON t_task (status, priority DESC, id);
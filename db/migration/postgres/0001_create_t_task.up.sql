@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS t_task
+(
+    id         BIGSERIAL PRIMARY KEY,
+    parameter  TEXT                    NOT NULL,
+    image1     BYTEA                   NULL,
+    image2     BYTEA                   NULL,
+    image3     BYTEA                   NULL,
+    image4     BYTEA                   NULL,
+    created_at TIMESTAMP DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMP DEFAULT NOW() NOT NULL,
+    user_id    BIGINT                  NOT NULL,
+    status     INTEGER   DEFAULT 1     NOT NULL,
+    message    TEXT                    NULL
+);
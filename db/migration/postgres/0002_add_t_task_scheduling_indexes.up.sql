@@ -0,0 +1,8 @@
+ALTER TABLE t_task ADD COLUMN model VARCHAR(64) DEFAULT '' NOT NULL;
+ALTER TABLE t_task ADD COLUMN priority INTEGER DEFAULT 0 NOT NULL;
+ALTER TABLE t_task ADD COLUMN next_run_at TIMESTAMP NULL;
+-- 早期的 parameter 不一定是合法的 JSON，无法解析的行保留空的 model，避免整个迁移失败
+CREATE FUNCTION pg_temp.try_json(value TEXT) RETURNS JSON AS $$ BEGIN RETURN value::json; EXCEPTION WHEN invalid_text_representation THEN RETURN NULL; END $$ LANGUAGE plpgsql;
+UPDATE t_task SET model = COALESCE(pg_temp.try_json(parameter)->>'model', '') WHERE parameter ~ '^\s*\{';
+CREATE INDEX idx_t_task_status_next_run_at ON t_task (status, next_run_at, priority, id);
+CREATE INDEX idx_t_task_user_id_created_at ON t_task (user_id, created_at);
@@ -0,0 +1 @@
+ALTER TABLE t_task ADD COLUMN attempts INT DEFAULT 0 NOT NULL;
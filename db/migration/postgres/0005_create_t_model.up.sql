@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS t_model
+(
+    id                 BIGSERIAL PRIMARY KEY,
+    name               VARCHAR(64)                NOT NULL,
+    display_name       VARCHAR(128) DEFAULT ''    NOT NULL,
+    adapter            VARCHAR(32)  DEFAULT ''    NOT NULL,
+    api                VARCHAR(512)               NOT NULL,
+    enabled            BOOLEAN      DEFAULT TRUE  NOT NULL,
+    concurrency        INTEGER      DEFAULT 0     NOT NULL,
+    default_parameters TEXT                       NULL,
+    headers            TEXT                       NULL,
+    created_at         TIMESTAMP    DEFAULT NOW() NOT NULL,
+    updated_at         TIMESTAMP    DEFAULT NOW() NOT NULL,
+    CONSTRAINT uk_t_model_name UNIQUE (name)
+);
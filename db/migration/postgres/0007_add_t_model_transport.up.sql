@@ -0,0 +1,2 @@
+ALTER TABLE t_model ADD COLUMN transport VARCHAR(16) DEFAULT '' NOT NULL;
+ALTER TABLE t_model ADD COLUMN retries INTEGER DEFAULT 0 NOT NULL;
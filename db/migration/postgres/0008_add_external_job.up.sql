@@ -0,0 +1,3 @@
+ALTER TABLE t_task ADD COLUMN external_job_id VARCHAR(128) NULL;
+ALTER TABLE t_task ADD COLUMN job_submitted_at TIMESTAMP NULL;
+ALTER TABLE t_model ADD COLUMN poll TEXT NULL;
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS t_task_input
+(
+    id           BIGSERIAL PRIMARY KEY,
+    task_id      BIGINT                     NOT NULL,
+    name         VARCHAR(32)                NOT NULL,
+    content_type VARCHAR(64)                NOT NULL,
+    data         BYTEA                      NOT NULL,
+    created_at   TIMESTAMP    DEFAULT NOW() NOT NULL,
+    CONSTRAINT uk_t_task_input_task_id_name UNIQUE (task_id, name)
+);
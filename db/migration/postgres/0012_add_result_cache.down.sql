@@ -0,0 +1,5 @@
+ALTER TABLE t_model DROP COLUMN cache;
+ALTER TABLE t_model DROP COLUMN version;
+DROP INDEX idx_t_task_cache_key;
+ALTER TABLE t_task DROP COLUMN cached_from;
+ALTER TABLE t_task DROP COLUMN cache_key;
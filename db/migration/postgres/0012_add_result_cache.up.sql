@@ -0,0 +1,5 @@
+ALTER TABLE t_task ADD COLUMN cache_key CHAR(64) NULL;
+ALTER TABLE t_task ADD COLUMN cached_from BIGINT NULL;
+CREATE INDEX idx_t_task_cache_key ON t_task (cache_key);
+ALTER TABLE t_model ADD COLUMN version VARCHAR(64) DEFAULT '' NOT NULL;
+ALTER TABLE t_model ADD COLUMN cache BOOLEAN DEFAULT FALSE NOT NULL;
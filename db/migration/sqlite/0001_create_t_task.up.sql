@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS t_task
+(
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    parameter  TEXT                               NOT NULL,
+    image1     BLOB                               NULL,
+    image2     BLOB                               NULL,
+    image3     BLOB                               NULL,
+    image4     BLOB                               NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    user_id    INTEGER                            NOT NULL,
+    status     INTEGER  DEFAULT 1                 NOT NULL,
+    message    TEXT                               NULL
+);
@@ -0,0 +1,6 @@
+ALTER TABLE t_task ADD COLUMN model VARCHAR(64) DEFAULT '' NOT NULL;
+ALTER TABLE t_task ADD COLUMN priority INTEGER DEFAULT 0 NOT NULL;
+ALTER TABLE t_task ADD COLUMN next_run_at DATETIME NULL;
+UPDATE t_task SET model = COALESCE(json_extract(parameter, '$.model'), '') WHERE json_valid(parameter);
+CREATE INDEX idx_t_task_status_next_run_at ON t_task (status, next_run_at, priority, id);
+CREATE INDEX idx_t_task_user_id_created_at ON t_task (user_id, created_at);
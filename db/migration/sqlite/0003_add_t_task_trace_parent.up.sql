@@ -0,0 +1 @@
+ALTER TABLE t_task ADD COLUMN trace_parent VARCHAR(64) NULL;
@@ -0,0 +1,2 @@
+ALTER TABLE t_model ADD COLUMN auth TEXT NULL;
+ALTER TABLE t_model ADD COLUMN tls TEXT NULL;
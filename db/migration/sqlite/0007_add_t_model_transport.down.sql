@@ -0,0 +1,2 @@
+ALTER TABLE t_model DROP COLUMN retries;
+ALTER TABLE t_model DROP COLUMN transport;
@@ -0,0 +1,3 @@
+ALTER TABLE t_model DROP COLUMN poll;
+ALTER TABLE t_task DROP COLUMN job_submitted_at;
+ALTER TABLE t_task DROP COLUMN external_job_id;
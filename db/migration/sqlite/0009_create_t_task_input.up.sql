@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS t_task_input
+(
+    id           INTEGER PRIMARY KEY AUTOINCREMENT,
+    task_id      INTEGER                            NOT NULL,
+    name         VARCHAR(32)                        NOT NULL,
+    content_type VARCHAR(64)                        NOT NULL,
+    data         BLOB                               NOT NULL,
+    created_at   DATETIME DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    CONSTRAINT uk_t_task_input_task_id_name UNIQUE (task_id, name)
+);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS t_task_output
+(
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    task_id     INTEGER                            NOT NULL,
+    seq         INTEGER                            NOT NULL,
+    kind        VARCHAR(16)                        NOT NULL,
+    mime_type   VARCHAR(128)                       NOT NULL,
+    storage_ref VARCHAR(512)                       NOT NULL,
+    size        INTEGER  DEFAULT 0                 NOT NULL,
+    data        BLOB                               NULL,
+    created_at  DATETIME DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    CONSTRAINT uk_t_task_output_task_id_seq UNIQUE (task_id, seq)
+);
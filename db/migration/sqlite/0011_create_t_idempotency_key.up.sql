@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS t_idempotency_key
+(
+    id              INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id         INTEGER                            NOT NULL,
+    idempotency_key VARCHAR(255)                       NOT NULL,
+    request_hash    CHAR(64)                           NOT NULL,
+    task_id         INTEGER                            NOT NULL,
+    created_at      DATETIME DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    expires_at      DATETIME                           NOT NULL,
+    CONSTRAINT uk_t_idempotency_key_user_id_key UNIQUE (user_id, idempotency_key)
+);
+CREATE INDEX idx_t_idempotency_key_expires_at ON t_idempotency_key (expires_at);
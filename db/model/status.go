@@ -0,0 +1,29 @@
+package model
+
+// Status 任务状态，对应 t_task.status
+type Status int32
+
+const (
+	StatusInit Status = iota + 1
+	StatusRunning
+	StatusSuccess
+	StatusFail
+)
+
+// Statuses 所有的任务状态
+var Statuses = []Status{StatusInit, StatusRunning, StatusSuccess, StatusFail}
+
+func (s Status) String() string {
+	switch s {
+	case StatusInit:
+		return "init"
+	case StatusRunning:
+		return "running"
+	case StatusSuccess:
+		return "success"
+	case StatusFail:
+		return "fail"
+	default:
+		return "unknown"
+	}
+}
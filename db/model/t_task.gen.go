@@ -12,17 +12,26 @@ const TableNameTask = "t_task"
 
 // Task mapped from table <t_task>
 type Task struct {
-	ID        int64     `gorm:"column:id;primaryKey;autoIncrement:true" json:"id"`
-	Parameter string    `gorm:"column:parameter;not null" json:"parameter"`
-	Image1    *[]byte   `gorm:"column:image1" json:"image1"`
-	Image2    *[]byte   `gorm:"column:image2" json:"image2"`
-	Image3    *[]byte   `gorm:"column:image3" json:"image3"`
-	Image4    *[]byte   `gorm:"column:image4" json:"image4"`
-	CreatedAt time.Time `gorm:"column:created_at;not null;default:CURRENT_TIMESTAMP" json:"created_at"`
-	UpdatedAt time.Time `gorm:"column:updated_at;not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
-	UserID    int64     `gorm:"column:user_id;not null" json:"user_id"`
-	Status    int32     `gorm:"column:status;not null;default:1" json:"status"`
-	Message   *string   `gorm:"column:message" json:"message"`
+	ID             int64      `gorm:"column:id;primaryKey;autoIncrement:true" json:"id"`
+	Parameter      string     `gorm:"column:parameter;not null" json:"parameter"`
+	Image1         *[]byte    `gorm:"column:image1" json:"image1"`
+	Image2         *[]byte    `gorm:"column:image2" json:"image2"`
+	Image3         *[]byte    `gorm:"column:image3" json:"image3"`
+	Image4         *[]byte    `gorm:"column:image4" json:"image4"`
+	CreatedAt      time.Time  `gorm:"column:created_at;not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt      time.Time  `gorm:"column:updated_at;not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
+	UserID         int64      `gorm:"column:user_id;not null" json:"user_id"`
+	Status         int32      `gorm:"column:status;not null;default:1" json:"status"`
+	Message        *string    `gorm:"column:message" json:"message"`
+	Model          string     `gorm:"column:model;not null" json:"model"`
+	Priority       int32      `gorm:"column:priority;not null" json:"priority"`
+	NextRunAt      *time.Time `gorm:"column:next_run_at" json:"next_run_at"`
+	TraceParent    *string    `gorm:"column:trace_parent" json:"trace_parent"`
+	Attempts       int32      `gorm:"column:attempts;not null" json:"attempts"`
+	ExternalJobID  *string    `gorm:"column:external_job_id" json:"external_job_id"`
+	JobSubmittedAt *time.Time `gorm:"column:job_submitted_at" json:"job_submitted_at"`
+	CacheKey       *string    `gorm:"column:cache_key" json:"cache_key"`
+	CachedFrom     *int64     `gorm:"column:cached_from" json:"cached_from"`
 }
 
 // TableName Task's table name
@@ -0,0 +1,121 @@
+package repository_test
+
+import (
+	"context"
+	"flag"
+	"gorm.io/gorm"
+	"math/rand"
+	"severless-task-scheduler/db/api"
+	"severless-task-scheduler/db/model"
+	"severless-task-scheduler/db/repository"
+	"strings"
+	"testing"
+)
+
+// go test ./db/repository -run '^$' -bench Claim -claim.rows 1000000
+var (
+	claimRows    = flag.Int("claim.rows", 100000, "number of tasks seeded for BenchmarkClaim")
+	claimPending = flag.Float64("claim.pending", 0.05, "ratio of seeded tasks left in init status")
+)
+
+const claimLimit = 10
+
+// seedTasks 在一个事务中按批写入任务，其中 pending 比例的任务为待执行，其余为成功
+func seedTasks(tb testing.TB, db *gorm.DB, rows int, pending float64) {
+	tb.Helper()
+	models := []string{"openjourney", "anything", "waifu", "nerverendDream"}
+	random := rand.New(rand.NewSource(1))
+	err := db.Transaction(
+		func(tx *gorm.DB) error {
+			batch := make([]*model.Task, 0, 1000)
+			for i := 0; i < rows; i++ {
+				task := &model.Task{
+					Parameter: `{"model":"openjourney","prompt":"benchmark"}`,
+					Model:     models[i%len(models)],
+					UserID:    int64(random.Intn(10000)),
+					Status:    int32(model.StatusSuccess),
+					Priority:  int32(random.Intn(3)),
+				}
+				if random.Float64() < pending {
+					task.Status = int32(model.StatusInit)
+				}
+				batch = append(batch, task)
+				if len(batch) == cap(batch) || i == rows-1 {
+					if err := tx.Create(&batch).Error; err != nil {
+						return err
+					}
+					batch = batch[:0]
+				}
+			}
+			return nil
+		},
+	)
+	if err != nil {
+		tb.Fatalf("seed tasks: %v", err)
+	}
+}
+
+// claimPlan 执行一次认领并返回认领查询的 SQLite 查询计划
+func claimPlan(tb testing.TB, db *gorm.DB, quotas map[string]int) string {
+	tb.Helper()
+	var query string
+	var vars []any
+	err := db.Callback().Query().After("gorm:query").Register(
+		"test:claim_plan", func(tx *gorm.DB) {
+			if query == "" && strings.Contains(tx.Statement.SQL.String(), "ORDER BY") {
+				query, vars = tx.Statement.SQL.String(), tx.Statement.Vars
+			}
+		},
+	)
+	if err != nil {
+		tb.Fatalf("register callback: %v", err)
+	}
+	defer db.Callback().Query().Remove("test:claim_plan")
+	if _, err = repository.NewGormTaskRepository(api.Use(db)).Claim(context.Background(), claimLimit, quotas); err != nil {
+		tb.Fatalf("claim: %v", err)
+	}
+	rows := make([]struct{ Detail string }, 0)
+	if err = db.Raw("EXPLAIN QUERY PLAN "+query, vars...).Scan(&rows).Error; err != nil {
+		tb.Fatalf("explain claim: %v", err)
+	}
+	details := make([]string, 0, len(rows))
+	for _, row := range rows {
+		details = append(details, row.Detail)
+	}
+	return strings.Join(details, "; ")
+}
+
+// TestClaimPlan 认领按索引顺序扫描，不对待执行的任务排序
+func TestClaimPlan(t *testing.T) {
+	db := openSQLite(t)
+	seedTasks(t, db, 2000, *claimPending)
+	plan := claimPlan(t, db, map[string]int{"waifu": 0})
+	if !strings.Contains(plan, "idx_t_task_status_priority_id") || strings.Contains(plan, "TEMP B-TREE") {
+		t.Errorf("got plan %q, want an index scan without sorting", plan)
+	}
+}
+
+func BenchmarkClaim(b *testing.B) {
+	db := openSQLite(b)
+	seedTasks(b, db, *claimRows, *claimPending)
+	r := repository.NewGormTaskRepository(api.Use(db))
+	quotas := map[string]int{"waifu": 0}
+	ctx := context.Background()
+	b.Logf("claim plan: %s", claimPlan(b, db, quotas))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tasks, err := r.Claim(ctx, claimLimit, quotas)
+		if err != nil {
+			b.Fatalf("claim: %v", err)
+		}
+		// 把认领的任务放回队列，使每次认领面对相同的数据量
+		b.StopTimer()
+		for _, task := range tasks {
+			if err = r.UpdateStatus(ctx, task.ID, model.StatusInit, nil); err != nil {
+				b.Fatalf("reset task: %v", err)
+			}
+		}
+		b.StartTimer()
+	}
+}
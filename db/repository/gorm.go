@@ -0,0 +1,440 @@
+package repository
+
+import (
+	"context"
+	"gorm.io/gen/field"
+	"severless-task-scheduler/db/api"
+	"severless-task-scheduler/db/model"
+	"time"
+)
+
+type gormTaskRepository struct {
+	query *api.Query
+}
+
+// NewGormTaskRepository 基于 gorm/gen 生成代码的实现
+func NewGormTaskRepository(query *api.Query) TaskRepository {
+	return &gormTaskRepository{query: query}
+}
+
+func (r *gormTaskRepository) Create(ctx context.Context, task *model.Task) error {
+	return r.query.Task.WithContext(ctx).Create(task)
+}
+
+func (r *gormTaskRepository) CreateWithInputs(ctx context.Context, task *model.Task, inputs []*model.TaskInput) error {
+	return r.query.Transaction(
+		func(tx *api.Query) error {
+			return insertTask(ctx, tx, task, inputs, nil)
+		},
+	)
+}
+
+func (r *gormTaskRepository) CreateWithKey(ctx context.Context, task *model.Task, inputs []*model.TaskInput, key *model.IdempotencyKey) error {
+	return r.query.Transaction(
+		func(tx *api.Query) error {
+			return insertTask(ctx, tx, task, inputs, key)
+		},
+	)
+}
+
+func (r *gormTaskRepository) CreateCached(
+	ctx context.Context, task *model.Task, inputs []*model.TaskInput, key *model.IdempotencyKey, sourceID int64,
+) (bool, error) {
+	created := false
+	err := r.query.Transaction(
+		func(tx *api.Query) error {
+			t, o := tx.Task, tx.TaskOutput
+			source, err := t.WithContext(ctx).
+				Where(t.ID.Eq(sourceID), t.Status.Eq(int32(model.StatusSuccess)), t.CacheKey.IsNotNull()).Find()
+			if err != nil || len(source) == 0 {
+				return err
+			}
+			outputs, err := o.WithContext(ctx).Where(o.TaskID.Eq(sourceID)).Order(o.Seq).Find()
+			if err != nil {
+				return err
+			}
+			task.Status = int32(model.StatusSuccess)
+			task.CachedFrom = &sourceID
+			task.Image1, task.Image2, task.Image3, task.Image4 = source[0].Image1, source[0].Image2, source[0].Image3, source[0].Image4
+			if err = insertTask(ctx, tx, task, inputs, key); err != nil {
+				return err
+			}
+			for _, output := range outputs {
+				output.ID = 0
+				output.TaskID = task.ID
+			}
+			if len(outputs) > 0 {
+				if err = o.WithContext(ctx).Create(outputs...); err != nil {
+					return err
+				}
+			}
+			created = true
+			return nil
+		},
+	)
+	return created && err == nil, err
+}
+
+// insertTask 在事务中创建任务、输入文件和幂等键，key 为 nil 时不创建幂等键
+func insertTask(ctx context.Context, tx *api.Query, task *model.Task, inputs []*model.TaskInput, key *model.IdempotencyKey) error {
+	k := tx.IdempotencyKey
+	if key != nil {
+		now := time.Now()
+		existing, err := k.WithContext(ctx).Where(k.UserID.Eq(key.UserID), k.IdempotencyKey.Eq(key.IdempotencyKey)).Find()
+		if err != nil {
+			return err
+		}
+		for _, e := range existing {
+			if e.ExpiresAt.After(now) {
+				return ErrDuplicateKey
+			}
+			// 过期的幂等键仍然占用唯一索引，先删除
+			if _, err = k.WithContext(ctx).Where(k.ID.Eq(e.ID)).Delete(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := tx.Task.WithContext(ctx).Create(task); err != nil {
+		return err
+	}
+	for _, input := range inputs {
+		input.TaskID = task.ID
+	}
+	if len(inputs) > 0 {
+		if err := tx.TaskInput.WithContext(ctx).Create(inputs...); err != nil {
+			return err
+		}
+	}
+	if key == nil {
+		return nil
+	}
+	key.TaskID = task.ID
+	// 并发请求使用同一个幂等键时，唯一索引保证只有一个事务成功
+	return k.WithContext(ctx).Create(key)
+}
+
+func (r *gormTaskRepository) GetKey(ctx context.Context, userID int64, key string) (*model.IdempotencyKey, error) {
+	k := r.query.IdempotencyKey
+	return k.WithContext(ctx).Where(k.UserID.Eq(userID), k.IdempotencyKey.Eq(key), k.ExpiresAt.Gt(time.Now())).First()
+}
+
+func (r *gormTaskRepository) DeleteExpiredKeys(ctx context.Context, before time.Time) (int64, error) {
+	k := r.query.IdempotencyKey
+	// 命中 idx_t_idempotency_key_expires_at
+	info, err := k.WithContext(ctx).Where(k.ExpiresAt.Lte(before)).Delete()
+	return info.RowsAffected, err
+}
+
+func (r *gormTaskRepository) ListInputs(ctx context.Context, taskID int64) ([]*model.TaskInput, error) {
+	i := r.query.TaskInput
+	return i.WithContext(ctx).Where(i.TaskID.Eq(taskID)).Order(i.ID).Find()
+}
+
+func (r *gormTaskRepository) Get(ctx context.Context, id int64) (*model.Task, error) {
+	t := r.query.Task
+	return t.WithContext(ctx).Where(t.ID.Eq(id)).First()
+}
+
+// summaryColumns 除图片以外的字段，调度和列表查询不需要加载图片
+func (r *gormTaskRepository) summaryColumns() []field.Expr {
+	t := r.query.Task
+	return []field.Expr{
+		t.ID, t.Parameter, t.CreatedAt, t.UpdatedAt, t.UserID, t.Status, t.Message,
+		t.Model, t.Priority, t.NextRunAt, t.TraceParent, t.Attempts, t.ExternalJobID, t.JobSubmittedAt,
+		t.CacheKey, t.CachedFrom,
+	}
+}
+
+func (r *gormTaskRepository) Claim(ctx context.Context, limit int, quotas map[string]int) ([]*model.Task, error) {
+	t := r.query.Task
+	// 按 idx_t_task_status_priority_id (status, priority DESC, id) 的顺序扫描，next_run_at 和模型在扫描时过滤，不需要排序
+	do := t.WithContext(ctx).Select(r.summaryColumns()...).
+		Where(t.Status.Eq(int32(model.StatusInit))).
+		Where(field.Or(t.NextRunAt.IsNull(), t.NextRunAt.Lte(time.Now())))
+	if exhausted := exhaustedModels(quotas); len(exhausted) > 0 {
+		do = do.Where(t.Model.NotIn(exhausted...))
+	}
+	tasks, err := do.Order(t.Priority.Desc(), t.ID).Limit(limit).Find()
+	if err != nil {
+		return nil, err
+	}
+	remaining := make(map[string]int, len(quotas))
+	for name, quota := range quotas {
+		remaining[name] = quota
+	}
+	claimed := make([]*model.Task, 0, len(tasks))
+	for _, task := range tasks {
+		if !takeQuota(remaining, task.Model) {
+			continue
+		}
+		// 只有状态仍为待执行的任务才能认领成功，避免多个调度者重复派发
+		info, err := t.WithContext(ctx).Where(t.ID.Eq(task.ID), t.Status.Eq(int32(model.StatusInit))).
+			UpdateSimple(t.Status.Value(int32(model.StatusRunning)), t.Attempts.Add(1))
+		if err != nil {
+			return claimed, err
+		}
+		if info.RowsAffected == 0 {
+			continue
+		}
+		task.Status = int32(model.StatusRunning)
+		task.Attempts++
+		claimed = append(claimed, task)
+	}
+	return claimed, nil
+}
+
+func (r *gormTaskRepository) UpdateStatus(ctx context.Context, id int64, status model.Status, message *string) error {
+	t := r.query.Task
+	_, err := t.WithContext(ctx).Where(t.ID.Eq(id)).UpdateColumns(
+		model.Task{
+			Status:  int32(status),
+			Message: message,
+		},
+	)
+	return err
+}
+
+func (r *gormTaskRepository) SubmitJob(ctx context.Context, id int64, jobID string, nextPollAt time.Time) error {
+	t := r.query.Task
+	_, err := t.WithContext(ctx).Where(t.ID.Eq(id)).UpdateSimple(
+		t.ExternalJobID.Value(jobID), t.JobSubmittedAt.Value(time.Now()), t.NextRunAt.Value(nextPollAt),
+	)
+	return err
+}
+
+func (r *gormTaskRepository) ClaimJobs(ctx context.Context, limit int, lease time.Duration) ([]*model.Task, error) {
+	t := r.query.Task
+	now := time.Now()
+	// 命中 idx_t_task_status_next_run_at
+	tasks, err := t.WithContext(ctx).Select(r.summaryColumns()...).
+		Where(t.Status.Eq(int32(model.StatusRunning)), t.NextRunAt.Lte(now), t.ExternalJobID.IsNotNull()).
+		Order(t.NextRunAt, t.ID).Limit(limit).Find()
+	if err != nil {
+		return nil, err
+	}
+	claimed := make([]*model.Task, 0, len(tasks))
+	for _, task := range tasks {
+		// 只有仍然到期的任务才能认领成功，避免多个调度者重复轮询
+		until := now.Add(lease)
+		info, err := t.WithContext(ctx).Where(t.ID.Eq(task.ID), t.Status.Eq(int32(model.StatusRunning)), t.NextRunAt.Lte(now)).
+			UpdateSimple(t.NextRunAt.Value(until))
+		if err != nil {
+			return claimed, err
+		}
+		if info.RowsAffected == 0 {
+			continue
+		}
+		task.NextRunAt = &until
+		claimed = append(claimed, task)
+	}
+	return claimed, nil
+}
+
+func (r *gormTaskRepository) DeferJob(ctx context.Context, id int64, nextPollAt time.Time) error {
+	t := r.query.Task
+	_, err := t.WithContext(ctx).Where(t.ID.Eq(id)).UpdateSimple(t.NextRunAt.Value(nextPollAt))
+	return err
+}
+
+func (r *gormTaskRepository) SaveResult(ctx context.Context, id int64, images [][]byte, outputs []*model.TaskOutput) error {
+	task := model.Task{
+		Status: int32(model.StatusSuccess),
+	}
+	imageColumns(&task, images)
+	return r.query.Transaction(
+		func(tx *api.Query) error {
+			t, o := tx.Task, tx.TaskOutput
+			if _, err := t.WithContext(ctx).Where(t.ID.Eq(id)).UpdateColumns(task); err != nil {
+				return err
+			}
+			// 重试的任务可能已经保存过输出
+			if _, err := o.WithContext(ctx).Where(o.TaskID.Eq(id)).Delete(); err != nil {
+				return err
+			}
+			if len(outputs) == 0 {
+				return nil
+			}
+			for _, output := range outputs {
+				output.TaskID = id
+			}
+			return o.WithContext(ctx).Create(outputs...)
+		},
+	)
+}
+
+func (r *gormTaskRepository) ListOutputs(ctx context.Context, taskID int64) ([]*model.TaskOutput, error) {
+	o := r.query.TaskOutput
+	return o.WithContext(ctx).
+		Select(o.ID, o.TaskID, o.Seq, o.Kind, o.MimeType, o.StorageRef, o.Size, o.CreatedAt).
+		Where(o.TaskID.Eq(taskID)).Order(o.Seq).Find()
+}
+
+func (r *gormTaskRepository) GetOutput(ctx context.Context, taskID int64, seq int32) (*model.TaskOutput, error) {
+	o := r.query.TaskOutput
+	return o.WithContext(ctx).Where(o.TaskID.Eq(taskID), o.Seq.Eq(seq)).First()
+}
+
+func (r *gormTaskRepository) ListOutputContents(ctx context.Context, taskIDs []int64) ([]*model.TaskOutput, error) {
+	if len(taskIDs) == 0 {
+		return []*model.TaskOutput{}, nil
+	}
+	o := r.query.TaskOutput
+	return o.WithContext(ctx).Where(o.TaskID.In(taskIDs...)).Order(o.TaskID, o.Seq).Find()
+}
+
+func (r *gormTaskRepository) FindCached(ctx context.Context, cacheKey string) (*model.Task, error) {
+	t := r.query.Task
+	// 命中 idx_t_task_cache_key
+	return t.WithContext(ctx).Select(r.summaryColumns()...).
+		Where(t.CacheKey.Eq(cacheKey), t.Status.Eq(int32(model.StatusSuccess))).
+		Order(t.ID.Desc()).First()
+}
+
+func (r *gormTaskRepository) CopyResult(ctx context.Context, id int64, sourceID int64) (bool, error) {
+	copied := false
+	err := r.query.Transaction(
+		func(tx *api.Query) error {
+			t, o := tx.Task, tx.TaskOutput
+			source, err := t.WithContext(ctx).
+				Where(t.ID.Eq(sourceID), t.Status.Eq(int32(model.StatusSuccess)), t.CacheKey.IsNotNull()).Find()
+			if err != nil || len(source) == 0 {
+				return err
+			}
+			outputs, err := o.WithContext(ctx).Where(o.TaskID.Eq(sourceID)).Order(o.Seq).Find()
+			if err != nil {
+				return err
+			}
+			// 只有尚未结束的任务才能复用结果，避免覆盖已经保存的结果
+			info, err := t.WithContext(ctx).
+				Where(t.ID.Eq(id), t.Status.In(int32(model.StatusInit), int32(model.StatusRunning))).
+				UpdateSimple(
+					t.Status.Value(int32(model.StatusSuccess)), t.CachedFrom.Value(sourceID),
+					t.Image1.Value(bytesOrNil(source[0].Image1)), t.Image2.Value(bytesOrNil(source[0].Image2)),
+					t.Image3.Value(bytesOrNil(source[0].Image3)), t.Image4.Value(bytesOrNil(source[0].Image4)),
+				)
+			if err != nil || info.RowsAffected == 0 {
+				return err
+			}
+			if _, err = o.WithContext(ctx).Where(o.TaskID.Eq(id)).Delete(); err != nil {
+				return err
+			}
+			for _, output := range outputs {
+				output.ID = 0
+				output.TaskID = id
+			}
+			if len(outputs) > 0 {
+				if err = o.WithContext(ctx).Create(outputs...); err != nil {
+					return err
+				}
+			}
+			copied = true
+			return nil
+		},
+	)
+	return copied && err == nil, err
+}
+
+func (r *gormTaskRepository) SetCacheKey(ctx context.Context, id int64, cacheKey *string) error {
+	t := r.query.Task
+	if cacheKey == nil {
+		_, err := t.WithContext(ctx).Where(t.ID.Eq(id)).UpdateSimple(t.CacheKey.Null())
+		return err
+	}
+	_, err := t.WithContext(ctx).Where(t.ID.Eq(id)).UpdateSimple(t.CacheKey.Value(*cacheKey))
+	return err
+}
+
+// bytesOrNil 可为空的图片字段的值
+func bytesOrNil(image *[]byte) []byte {
+	if image == nil {
+		return nil
+	}
+	return *image
+}
+
+func (r *gormTaskRepository) List(ctx context.Context, options ListOptions) ([]*model.Task, error) {
+	t := r.query.Task
+	do := t.WithContext(ctx).Select(r.summaryColumns()...).Order(t.ID)
+	if options.WithImages {
+		do = t.WithContext(ctx).Order(t.ID)
+	}
+	if options.Status != nil {
+		do = do.Where(t.Status.Eq(int32(*options.Status)))
+	}
+	if options.AfterID > 0 {
+		do = do.Where(t.ID.Gt(options.AfterID))
+	}
+	if options.CreatedBefore != nil {
+		do = do.Where(t.CreatedAt.Lt(*options.CreatedBefore))
+	}
+	if options.HasImages {
+		o := r.query.TaskOutput
+		inline := o.WithContext(ctx).Select(o.TaskID).Where(o.Data.IsNotNull())
+		do = do.Where(field.Or(t.Image1.IsNotNull(), do.Columns(t.ID).In(inline)))
+	}
+	if options.Limit > 0 {
+		do = do.Limit(options.Limit)
+	}
+	return do.Find()
+}
+
+func (r *gormTaskRepository) CountByStatus(ctx context.Context, statuses ...model.Status) (map[model.Status]map[string]int64, error) {
+	t := r.query.Task
+	values := make([]int32, 0, len(statuses))
+	for _, status := range statuses {
+		values = append(values, int32(status))
+	}
+	rows := make([]statusCount, 0)
+	// 只统计少量状态时按 idx_t_task_status_next_run_at 的 status 前缀范围扫描
+	err := t.WithContext(ctx).Select(t.Status, t.Model, t.ID.Count().As("count")).
+		Where(t.Status.In(values...)).
+		Group(t.Status, t.Model).
+		Scan(&rows)
+	if err != nil {
+		return nil, err
+	}
+	return groupCounts(statuses, rows), nil
+}
+
+func (r *gormTaskRepository) PurgeImages(ctx context.Context, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.query.Transaction(
+		func(tx *api.Query) error {
+			t, i, o := tx.Task, tx.TaskInput, tx.TaskOutput
+			// 清空图片后结果不能再被复用
+			_, err := t.WithContext(ctx).Where(t.ID.In(ids...)).
+				UpdateSimple(t.Image1.Null(), t.Image2.Null(), t.Image3.Null(), t.Image4.Null(), t.CacheKey.Null())
+			if err != nil {
+				return err
+			}
+			// 保留输出的类型和大小，只清空内容
+			_, err = o.WithContext(ctx).Where(o.TaskID.In(ids...), o.Data.IsNotNull()).UpdateSimple(o.Data.Null())
+			if err != nil {
+				return err
+			}
+			_, err = i.WithContext(ctx).Where(i.TaskID.In(ids...)).Delete()
+			return err
+		},
+	)
+}
+
+func (r *gormTaskRepository) Delete(ctx context.Context, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.query.Transaction(
+		func(tx *api.Query) error {
+			t, i, o := tx.Task, tx.TaskInput, tx.TaskOutput
+			if _, err := i.WithContext(ctx).Where(i.TaskID.In(ids...)).Delete(); err != nil {
+				return err
+			}
+			if _, err := o.WithContext(ctx).Where(o.TaskID.In(ids...)).Delete(); err != nil {
+				return err
+			}
+			_, err := t.WithContext(ctx).Where(t.ID.In(ids...)).Delete()
+			return err
+		},
+	)
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"gorm.io/gen/field"
+	"gorm.io/gorm"
+	"severless-task-scheduler/db/api"
+	"severless-task-scheduler/db/model"
+	"time"
+)
+
+type gormModelRepository struct {
+	query *api.Query
+}
+
+// NewGormModelRepository 基于 gorm/gen 生成代码的实现
+func NewGormModelRepository(query *api.Query) ModelRepository {
+	return &gormModelRepository{query: query}
+}
+
+func (r *gormModelRepository) Create(ctx context.Context, m *model.RegisteredModel) error {
+	err := r.query.RegisteredModel.WithContext(ctx).Create(m)
+	if err != nil || m.Enabled {
+		return err
+	}
+	// enabled 有默认值，值为 false 时 Create 不会写入该字段
+	return r.SetEnabled(ctx, m.Name, false)
+}
+
+func (r *gormModelRepository) Update(ctx context.Context, m *model.RegisteredModel) error {
+	t := r.query.RegisteredModel
+	m.UpdatedAt = time.Now()
+	assigns := []field.AssignExpr{
+		t.DisplayName.Value(m.DisplayName),
+		t.Adapter.Value(m.Adapter),
+		t.API.Value(m.API),
+		t.Transport.Value(m.Transport),
+		t.Retries.Value(m.Retries),
+		t.Version.Value(m.Version),
+		t.Cache.Value(m.Cache),
+		t.Enabled.Value(m.Enabled),
+		t.Concurrency.Value(m.Concurrency),
+		t.UpdatedAt.Value(m.UpdatedAt),
+		nullableString(t.DefaultParameters, m.DefaultParameters),
+		nullableString(t.Headers, m.Headers),
+		nullableString(t.Auth, m.Auth),
+		nullableString(t.TLS, m.TLS),
+		nullableString(t.Poll, m.Poll),
+	}
+	info, err := t.WithContext(ctx).Where(t.Name.Eq(m.Name)).UpdateSimple(assigns...)
+	if err != nil {
+		return err
+	}
+	if info.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func nullableString(column field.String, value *string) field.AssignExpr {
+	if value == nil {
+		return column.Null()
+	}
+	return column.Value(*value)
+}
+
+func (r *gormModelRepository) Get(ctx context.Context, name string) (*model.RegisteredModel, error) {
+	t := r.query.RegisteredModel
+	return t.WithContext(ctx).Where(t.Name.Eq(name)).First()
+}
+
+func (r *gormModelRepository) List(ctx context.Context, enabledOnly bool) ([]*model.RegisteredModel, error) {
+	t := r.query.RegisteredModel
+	do := t.WithContext(ctx).Order(t.Name)
+	if enabledOnly {
+		do = do.Where(t.Enabled.Is(true))
+	}
+	return do.Find()
+}
+
+func (r *gormModelRepository) SetEnabled(ctx context.Context, name string, enabled bool) error {
+	t := r.query.RegisteredModel
+	info, err := t.WithContext(ctx).Where(t.Name.Eq(name)).UpdateSimple(t.Enabled.Value(enabled), t.UpdatedAt.Value(time.Now()))
+	if err != nil {
+		return err
+	}
+	if info.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
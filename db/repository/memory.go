@@ -0,0 +1,506 @@
+package repository
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"severless-task-scheduler/db/model"
+	"sort"
+	"sync"
+	"time"
+)
+
+type memoryTaskRepository struct {
+	mu      sync.Mutex
+	nextID  int64
+	tasks   map[int64]*model.Task
+	inputs  map[int64][]*model.TaskInput
+	outputs map[int64][]*model.TaskOutput
+	keys    map[memoryKey]*model.IdempotencyKey
+}
+
+type memoryKey struct {
+	userID int64
+	key    string
+}
+
+// NewMemoryTaskRepository 基于内存的实现，用于测试和本地开发
+func NewMemoryTaskRepository() TaskRepository {
+	return &memoryTaskRepository{
+		nextID:  1,
+		tasks:   make(map[int64]*model.Task),
+		inputs:  make(map[int64][]*model.TaskInput),
+		outputs: make(map[int64][]*model.TaskOutput),
+		keys:    make(map[memoryKey]*model.IdempotencyKey),
+	}
+}
+
+func (r *memoryTaskRepository) Create(ctx context.Context, task *model.Task) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.insert(task, nil)
+	return nil
+}
+
+func (r *memoryTaskRepository) CreateWithInputs(ctx context.Context, task *model.Task, inputs []*model.TaskInput) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.insert(task, inputs)
+	return nil
+}
+
+// insert 保存任务和输入文件并回填 ID 等字段，调用方需持有锁
+func (r *memoryTaskRepository) insert(task *model.Task, inputs []*model.TaskInput) {
+	now := time.Now()
+	task.ID = r.nextID
+	task.CreatedAt = now
+	task.UpdatedAt = now
+	if task.Status == 0 {
+		task.Status = int32(model.StatusInit)
+	}
+	r.nextID++
+	stored := *task
+	r.tasks[task.ID] = &stored
+	if len(inputs) == 0 {
+		return
+	}
+	storedInputs := make([]*model.TaskInput, 0, len(inputs))
+	for _, input := range inputs {
+		input.TaskID = task.ID
+		input.CreatedAt = task.CreatedAt
+		copied := *input
+		storedInputs = append(storedInputs, &copied)
+	}
+	r.inputs[task.ID] = storedInputs
+}
+
+func (r *memoryTaskRepository) CreateWithKey(ctx context.Context, task *model.Task, inputs []*model.TaskInput, key *model.IdempotencyKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// 在同一次加锁中检查幂等键并创建任务，并发的相同请求能查到已创建的任务
+	id := memoryKey{userID: key.UserID, key: key.IdempotencyKey}
+	if existing, ok := r.keys[id]; ok && existing.ExpiresAt.After(time.Now()) {
+		return ErrDuplicateKey
+	}
+	r.insert(task, inputs)
+	key.TaskID = task.ID
+	key.CreatedAt = task.CreatedAt
+	copied := *key
+	r.keys[id] = &copied
+	return nil
+}
+
+func (r *memoryTaskRepository) CreateCached(
+	ctx context.Context, task *model.Task, inputs []*model.TaskInput, key *model.IdempotencyKey, sourceID int64,
+) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	source, ok := r.tasks[sourceID]
+	if !ok || source.Status != int32(model.StatusSuccess) || source.CacheKey == nil {
+		return false, nil
+	}
+	var id memoryKey
+	if key != nil {
+		id = memoryKey{userID: key.UserID, key: key.IdempotencyKey}
+		if existing, ok := r.keys[id]; ok && existing.ExpiresAt.After(time.Now()) {
+			return false, ErrDuplicateKey
+		}
+	}
+	task.Status = int32(model.StatusSuccess)
+	task.CachedFrom = &sourceID
+	task.Image1, task.Image2, task.Image3, task.Image4 = source.Image1, source.Image2, source.Image3, source.Image4
+	r.insert(task, inputs)
+	outputs := make([]*model.TaskOutput, 0, len(r.outputs[sourceID]))
+	for _, output := range r.outputs[sourceID] {
+		copied := *output
+		copied.TaskID = task.ID
+		copied.CreatedAt = task.CreatedAt
+		outputs = append(outputs, &copied)
+	}
+	r.outputs[task.ID] = outputs
+	if key != nil {
+		key.TaskID = task.ID
+		key.CreatedAt = task.CreatedAt
+		copied := *key
+		r.keys[id] = &copied
+	}
+	return true, nil
+}
+
+func (r *memoryTaskRepository) GetKey(ctx context.Context, userID int64, key string) (*model.IdempotencyKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.keys[memoryKey{userID: userID, key: key}]
+	if !ok || !existing.ExpiresAt.After(time.Now()) {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *existing
+	return &copied, nil
+}
+
+func (r *memoryTaskRepository) DeleteExpiredKeys(ctx context.Context, before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var deleted int64
+	for id, key := range r.keys {
+		if !key.ExpiresAt.After(before) {
+			delete(r.keys, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func (r *memoryTaskRepository) ListInputs(ctx context.Context, taskID int64) ([]*model.TaskInput, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	inputs := make([]*model.TaskInput, 0, len(r.inputs[taskID]))
+	for _, input := range r.inputs[taskID] {
+		copied := *input
+		inputs = append(inputs, &copied)
+	}
+	return inputs, nil
+}
+
+func (r *memoryTaskRepository) Get(ctx context.Context, id int64) (*model.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	task, ok := r.tasks[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	result := *task
+	return &result, nil
+}
+
+func (r *memoryTaskRepository) Claim(ctx context.Context, limit int, quotas map[string]int) ([]*model.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	candidates := make([]*model.Task, 0)
+	for _, task := range r.sorted() {
+		if task.Status != int32(model.StatusInit) {
+			continue
+		}
+		if task.NextRunAt != nil && task.NextRunAt.After(now) {
+			continue
+		}
+		candidates = append(candidates, task)
+	}
+	sort.SliceStable(
+		candidates, func(i, j int) bool {
+			return candidates[i].Priority > candidates[j].Priority
+		},
+	)
+
+	remaining := make(map[string]int, len(quotas))
+	for name, quota := range quotas {
+		remaining[name] = quota
+	}
+	claimed := make([]*model.Task, 0)
+	for _, task := range candidates {
+		if len(claimed) >= limit {
+			break
+		}
+		if !takeQuota(remaining, task.Model) {
+			continue
+		}
+		task.Status = int32(model.StatusRunning)
+		task.Attempts++
+		task.UpdatedAt = now
+		result := *task
+		result.Image1, result.Image2, result.Image3, result.Image4 = nil, nil, nil, nil
+		claimed = append(claimed, &result)
+	}
+	return claimed, nil
+}
+
+func (r *memoryTaskRepository) UpdateStatus(ctx context.Context, id int64, status model.Status, message *string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	task, ok := r.tasks[id]
+	if !ok {
+		return nil
+	}
+	task.Status = int32(status)
+	if message != nil {
+		task.Message = message
+	}
+	task.UpdatedAt = time.Now()
+	return nil
+}
+
+func (r *memoryTaskRepository) SubmitJob(ctx context.Context, id int64, jobID string, nextPollAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	task, ok := r.tasks[id]
+	if !ok {
+		return nil
+	}
+	now := time.Now()
+	task.ExternalJobID = &jobID
+	task.JobSubmittedAt = &now
+	task.NextRunAt = &nextPollAt
+	task.UpdatedAt = now
+	return nil
+}
+
+func (r *memoryTaskRepository) ClaimJobs(ctx context.Context, limit int, lease time.Duration) ([]*model.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	claimed := make([]*model.Task, 0)
+	for _, task := range r.sorted() {
+		if len(claimed) >= limit {
+			break
+		}
+		if task.Status != int32(model.StatusRunning) || task.ExternalJobID == nil {
+			continue
+		}
+		if task.NextRunAt == nil || task.NextRunAt.After(now) {
+			continue
+		}
+		until := now.Add(lease)
+		task.NextRunAt = &until
+		result := *task
+		result.Image1, result.Image2, result.Image3, result.Image4 = nil, nil, nil, nil
+		claimed = append(claimed, &result)
+	}
+	return claimed, nil
+}
+
+func (r *memoryTaskRepository) DeferJob(ctx context.Context, id int64, nextPollAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if task, ok := r.tasks[id]; ok {
+		task.NextRunAt = &nextPollAt
+	}
+	return nil
+}
+
+func (r *memoryTaskRepository) SaveResult(ctx context.Context, id int64, images [][]byte, outputs []*model.TaskOutput) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	task, ok := r.tasks[id]
+	if !ok {
+		return nil
+	}
+	task.Status = int32(model.StatusSuccess)
+	imageColumns(task, images)
+	task.UpdatedAt = time.Now()
+	stored := make([]*model.TaskOutput, 0, len(outputs))
+	for _, output := range outputs {
+		output.TaskID = id
+		output.CreatedAt = task.UpdatedAt
+		copied := *output
+		stored = append(stored, &copied)
+	}
+	r.outputs[id] = stored
+	return nil
+}
+
+func (r *memoryTaskRepository) ListOutputs(ctx context.Context, taskID int64) ([]*model.TaskOutput, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	outputs := make([]*model.TaskOutput, 0, len(r.outputs[taskID]))
+	for _, output := range r.outputs[taskID] {
+		copied := *output
+		copied.Data = nil
+		outputs = append(outputs, &copied)
+	}
+	return outputs, nil
+}
+
+func (r *memoryTaskRepository) GetOutput(ctx context.Context, taskID int64, seq int32) (*model.TaskOutput, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, output := range r.outputs[taskID] {
+		if output.Seq == seq {
+			copied := *output
+			return &copied, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *memoryTaskRepository) ListOutputContents(ctx context.Context, taskIDs []int64) ([]*model.TaskOutput, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	sorted := append([]int64{}, taskIDs...)
+	sort.Slice(
+		sorted, func(i, j int) bool {
+			return sorted[i] < sorted[j]
+		},
+	)
+	outputs := make([]*model.TaskOutput, 0)
+	for _, id := range sorted {
+		for _, output := range r.outputs[id] {
+			copied := *output
+			outputs = append(outputs, &copied)
+		}
+	}
+	return outputs, nil
+}
+
+func (r *memoryTaskRepository) FindCached(ctx context.Context, cacheKey string) (*model.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tasks := r.sorted()
+	for i := len(tasks) - 1; i >= 0; i-- {
+		task := tasks[i]
+		if task.CacheKey != nil && *task.CacheKey == cacheKey && task.Status == int32(model.StatusSuccess) {
+			found := *task
+			found.Image1, found.Image2, found.Image3, found.Image4 = nil, nil, nil, nil
+			return &found, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *memoryTaskRepository) CopyResult(ctx context.Context, id int64, sourceID int64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	source, ok := r.tasks[sourceID]
+	if !ok || source.Status != int32(model.StatusSuccess) || source.CacheKey == nil {
+		return false, nil
+	}
+	task, ok := r.tasks[id]
+	if !ok || (task.Status != int32(model.StatusInit) && task.Status != int32(model.StatusRunning)) {
+		return false, nil
+	}
+	task.Status = int32(model.StatusSuccess)
+	task.CachedFrom = &sourceID
+	task.Image1, task.Image2, task.Image3, task.Image4 = source.Image1, source.Image2, source.Image3, source.Image4
+	task.UpdatedAt = time.Now()
+	outputs := make([]*model.TaskOutput, 0, len(r.outputs[sourceID]))
+	for _, output := range r.outputs[sourceID] {
+		copied := *output
+		copied.TaskID = id
+		copied.CreatedAt = task.UpdatedAt
+		outputs = append(outputs, &copied)
+	}
+	r.outputs[id] = outputs
+	return true, nil
+}
+
+func (r *memoryTaskRepository) SetCacheKey(ctx context.Context, id int64, cacheKey *string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if task, ok := r.tasks[id]; ok {
+		task.CacheKey = cacheKey
+	}
+	return nil
+}
+
+func (r *memoryTaskRepository) List(ctx context.Context, options ListOptions) ([]*model.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := make([]*model.Task, 0)
+	for _, task := range r.sorted() {
+		if options.Limit > 0 && len(result) >= options.Limit {
+			break
+		}
+		if options.Status != nil && task.Status != int32(*options.Status) {
+			continue
+		}
+		if task.ID <= options.AfterID {
+			continue
+		}
+		if options.CreatedBefore != nil && !task.CreatedAt.Before(*options.CreatedBefore) {
+			continue
+		}
+		if options.HasImages && task.Image1 == nil && !r.hasInlineOutputs(task.ID) {
+			continue
+		}
+		listed := *task
+		if !options.WithImages {
+			listed.Image1, listed.Image2, listed.Image3, listed.Image4 = nil, nil, nil, nil
+		}
+		result = append(result, &listed)
+	}
+	return result, nil
+}
+
+func (r *memoryTaskRepository) CountByStatus(ctx context.Context, statuses ...model.Status) (map[model.Status]map[string]int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rows := make([]statusCount, 0, len(r.tasks))
+	for _, task := range r.tasks {
+		rows = append(rows, statusCount{Status: task.Status, Model: task.Model, Count: 1})
+	}
+	return groupCounts(statuses, rows), nil
+}
+
+func (r *memoryTaskRepository) PurgeImages(ctx context.Context, ids []int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, id := range ids {
+		if task, ok := r.tasks[id]; ok {
+			task.Image1, task.Image2, task.Image3, task.Image4 = nil, nil, nil, nil
+			task.CacheKey = nil
+		}
+		for _, output := range r.outputs[id] {
+			output.Data = nil
+		}
+		delete(r.inputs, id)
+	}
+	return nil
+}
+
+func (r *memoryTaskRepository) Delete(ctx context.Context, ids []int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, id := range ids {
+		delete(r.tasks, id)
+		delete(r.inputs, id)
+		delete(r.outputs, id)
+	}
+	return nil
+}
+
+// hasInlineOutputs 任务是否有内容保存在 t_task_output.data 中的输出，调用方需持有锁
+func (r *memoryTaskRepository) hasInlineOutputs(id int64) bool {
+	for _, output := range r.outputs[id] {
+		if output.Data != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// sorted 按 ID 升序返回所有任务，调用方需持有锁
+func (r *memoryTaskRepository) sorted() []*model.Task {
+	tasks := make([]*model.Task, 0, len(r.tasks))
+	for _, task := range r.tasks {
+		tasks = append(tasks, task)
+	}
+	sort.Slice(
+		tasks, func(i, j int) bool {
+			return tasks[i].ID < tasks[j].ID
+		},
+	)
+	return tasks
+}
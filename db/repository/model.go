@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"context"
+	"severless-task-scheduler/db/model"
+)
+
+// ModelRepository 模型注册表的存储接口，记录不存在时返回 gorm.ErrRecordNotFound
+type ModelRepository interface {
+	// Create 创建模型，成功后回填 ID 等字段
+	Create(ctx context.Context, m *model.RegisteredModel) error
+	// Update 按名称更新模型的所有可修改字段
+	Update(ctx context.Context, m *model.RegisteredModel) error
+	// Get 根据名称获取模型
+	Get(ctx context.Context, name string) (*model.RegisteredModel, error)
+	// List 列出模型，按名称升序排列，enabledOnly 为 true 时只返回启用的模型
+	List(ctx context.Context, enabledOnly bool) ([]*model.RegisteredModel, error)
+	// SetEnabled 启用或停用模型
+	SetEnabled(ctx context.Context, name string, enabled bool) error
+}
@@ -0,0 +1,444 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"path/filepath"
+	"severless-task-scheduler/config"
+	"severless-task-scheduler/db/api"
+	"severless-task-scheduler/db/driver"
+	"severless-task-scheduler/db/migration"
+	"severless-task-scheduler/db/model"
+	"severless-task-scheduler/db/repository"
+	"sync"
+	"testing"
+	"time"
+)
+
+// openSQLite 在临时目录中创建 SQLite 数据库并执行全部迁移
+func openSQLite(tb testing.TB) *gorm.DB {
+	tb.Helper()
+	db, err := driver.Open(
+		config.DatabaseConfig{Driver: driver.SQLite, DSN: filepath.Join(tb.TempDir(), "test.db")},
+		&gorm.Config{Logger: logger.Default.LogMode(logger.Silent)},
+	)
+	if err != nil {
+		tb.Fatalf("open database: %v", err)
+	}
+	migrator, err := migration.New(db)
+	if err != nil {
+		tb.Fatalf("load migrations: %v", err)
+	}
+	if _, err = migrator.Up(0); err != nil {
+		tb.Fatalf("migrate: %v", err)
+	}
+	tb.Cleanup(
+		func() {
+			if sqlDB, err := db.DB(); err == nil {
+				_ = sqlDB.Close()
+			}
+		},
+	)
+	return db
+}
+
+// eachRepository 对内存和 SQLite 上的 gorm 实现分别执行同一个测试
+func eachRepository(t *testing.T, test func(t *testing.T, r repository.TaskRepository)) {
+	t.Run(
+		"memory", func(t *testing.T) {
+			test(t, repository.NewMemoryTaskRepository())
+		},
+	)
+	t.Run(
+		"gorm", func(t *testing.T) {
+			test(t, repository.NewGormTaskRepository(api.Use(openSQLite(t))))
+		},
+	)
+}
+
+func createTask(t *testing.T, r repository.TaskRepository, task *model.Task) *model.Task {
+	t.Helper()
+	if task.Parameter == "" {
+		task.Parameter = `{"model":"` + task.Model + `","prompt":"test"}`
+	}
+	if err := r.Create(context.Background(), task); err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	return task
+}
+
+func getTask(t *testing.T, r repository.TaskRepository, id int64) *model.Task {
+	t.Helper()
+	task, err := r.Get(context.Background(), id)
+	if err != nil {
+		t.Fatalf("get task %d: %v", id, err)
+	}
+	return task
+}
+
+func TestCreateAndGet(t *testing.T) {
+	eachRepository(
+		t, func(t *testing.T, r repository.TaskRepository) {
+			task := createTask(t, r, &model.Task{Model: "openjourney"})
+			if task.ID == 0 {
+				t.Fatal("id is not filled")
+			}
+			got := getTask(t, r, task.ID)
+			if got.Status != int32(model.StatusInit) || got.Model != "openjourney" {
+				t.Errorf("got status %d model %q, want init openjourney", got.Status, got.Model)
+			}
+			if _, err := r.Get(context.Background(), task.ID+100); !errors.Is(err, gorm.ErrRecordNotFound) {
+				t.Errorf("get missing task: got %v, want ErrRecordNotFound", err)
+			}
+		},
+	)
+}
+
+func TestClaim(t *testing.T) {
+	eachRepository(
+		t, func(t *testing.T, r repository.TaskRepository) {
+			ctx := context.Background()
+			low := createTask(t, r, &model.Task{Model: "a"})
+			high := createTask(t, r, &model.Task{Model: "a", Priority: 1})
+			limited := createTask(t, r, &model.Task{Model: "b"})
+			later := time.Now().Add(time.Hour)
+			createTask(t, r, &model.Task{Model: "a", NextRunAt: &later})
+
+			claimed, err := r.Claim(ctx, 10, map[string]int{"b": 0})
+			if err != nil {
+				t.Fatalf("claim: %v", err)
+			}
+			if len(claimed) != 2 || claimed[0].ID != high.ID || claimed[1].ID != low.ID {
+				t.Fatalf("got %d tasks, want the higher priority task first and no quota-limited or deferred task", len(claimed))
+			}
+			for _, task := range claimed {
+				if task.Status != int32(model.StatusRunning) || task.Attempts != 1 {
+					t.Errorf("task %d: got status %d attempts %d, want running and 1", task.ID, task.Status, task.Attempts)
+				}
+			}
+			if got := getTask(t, r, limited.ID); got.Status != int32(model.StatusInit) {
+				t.Errorf("quota-limited task: got status %d, want init", got.Status)
+			}
+			claimed, err = r.Claim(ctx, 10, nil)
+			if err != nil {
+				t.Fatalf("claim again: %v", err)
+			}
+			if len(claimed) != 1 || claimed[0].ID != limited.ID {
+				t.Errorf("got %d tasks, want only the task without quota", len(claimed))
+			}
+		},
+	)
+}
+
+func TestSaveResult(t *testing.T) {
+	eachRepository(
+		t, func(t *testing.T, r repository.TaskRepository) {
+			ctx := context.Background()
+			task := createTask(t, r, &model.Task{Model: "a"})
+			text := []byte("hello")
+			outputs := []*model.TaskOutput{
+				{Seq: 0, Kind: "image", MimeType: "image/png", StorageRef: "t_task.image1", Size: 3},
+				{Seq: 1, Kind: "text", MimeType: "text/plain", StorageRef: "t_task_output.data", Size: 5, Data: &text},
+			}
+			if err := r.SaveResult(ctx, task.ID, [][]byte{[]byte("AAAA")}, outputs); err != nil {
+				t.Fatalf("save result: %v", err)
+			}
+			got := getTask(t, r, task.ID)
+			if got.Status != int32(model.StatusSuccess) || got.Image1 == nil || string(*got.Image1) != "AAAA" {
+				t.Fatalf("got status %d image1 %v, want success with the saved image", got.Status, got.Image1)
+			}
+			listed, err := r.ListOutputs(ctx, task.ID)
+			if err != nil {
+				t.Fatalf("list outputs: %v", err)
+			}
+			if len(listed) != 2 || listed[1].Kind != "text" || listed[1].Data != nil {
+				t.Fatalf("got %d outputs, want 2 without data", len(listed))
+			}
+			output, err := r.GetOutput(ctx, task.ID, 1)
+			if err != nil {
+				t.Fatalf("get output: %v", err)
+			}
+			if output.Data == nil || string(*output.Data) != "hello" {
+				t.Errorf("got output data %v, want hello", output.Data)
+			}
+			if _, err = r.GetOutput(ctx, task.ID, 2); !errors.Is(err, gorm.ErrRecordNotFound) {
+				t.Errorf("get missing output: got %v, want ErrRecordNotFound", err)
+			}
+		},
+	)
+}
+
+func TestCreateWithKey(t *testing.T) {
+	eachRepository(
+		t, func(t *testing.T, r repository.TaskRepository) {
+			ctx := context.Background()
+			key := &model.IdempotencyKey{UserID: 1, IdempotencyKey: "k", RequestHash: "h", ExpiresAt: time.Now().Add(time.Hour)}
+			task := &model.Task{Parameter: "{}", Model: "a"}
+			if err := r.CreateWithKey(ctx, task, nil, key); err != nil {
+				t.Fatalf("create with key: %v", err)
+			}
+			got, err := r.GetKey(ctx, 1, "k")
+			if err != nil || got.TaskID != task.ID {
+				t.Fatalf("get key: got %v %v, want the key of task %d", got, err, task.ID)
+			}
+			duplicate := &model.IdempotencyKey{UserID: 1, IdempotencyKey: "k", RequestHash: "h", ExpiresAt: time.Now().Add(time.Hour)}
+			if err = r.CreateWithKey(ctx, &model.Task{Parameter: "{}", Model: "a"}, nil, duplicate); !errors.Is(err, repository.ErrDuplicateKey) {
+				t.Errorf("create with duplicate key: got %v, want ErrDuplicateKey", err)
+			}
+			if _, err = r.GetKey(ctx, 2, "k"); !errors.Is(err, gorm.ErrRecordNotFound) {
+				t.Errorf("get key of another user: got %v, want ErrRecordNotFound", err)
+			}
+			deleted, err := r.DeleteExpiredKeys(ctx, time.Now().Add(2*time.Hour))
+			if err != nil || deleted != 1 {
+				t.Errorf("delete expired keys: got %d %v, want 1", deleted, err)
+			}
+		},
+	)
+}
+
+func TestCreateWithKeyConcurrent(t *testing.T) {
+	eachRepository(
+		t, func(t *testing.T, r repository.TaskRepository) {
+			ctx := context.Background()
+			const n = 32
+			var (
+				start   = make(chan struct{})
+				wg      sync.WaitGroup
+				mu      sync.Mutex
+				created []int64
+				found   []int64
+			)
+			for i := 0; i < n; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					key := &model.IdempotencyKey{UserID: 1, IdempotencyKey: "k", RequestHash: "h", ExpiresAt: time.Now().Add(time.Hour)}
+					task := &model.Task{Parameter: "{}", Model: "a"}
+					// 输入较多时创建任务耗时更长，更容易和重复请求交错
+					inputs := make([]*model.TaskInput, 2000)
+					for j := range inputs {
+						inputs[j] = &model.TaskInput{Name: fmt.Sprintf("image%d", j), ContentType: "image/png", Data: []byte("AAAA")}
+					}
+					<-start
+					if createErr := r.CreateWithKey(ctx, task, inputs, key); createErr != nil {
+						// 重复请求失败后必须能查到已创建任务的幂等键，才能重放结果
+						got, err := r.GetKey(ctx, 1, "k")
+						if err != nil {
+							t.Errorf("get key after duplicate: %v (create: %v)", err, createErr)
+							return
+						}
+						mu.Lock()
+						found = append(found, got.TaskID)
+						mu.Unlock()
+						return
+					}
+					mu.Lock()
+					created = append(created, task.ID)
+					mu.Unlock()
+				}()
+			}
+			close(start)
+			wg.Wait()
+			if len(created) != 1 {
+				t.Fatalf("created %d tasks, want 1", len(created))
+			}
+			for _, id := range found {
+				if id != created[0] {
+					t.Errorf("duplicate saw key of task %d, want %d", id, created[0])
+				}
+			}
+		},
+	)
+}
+
+func TestCopyResult(t *testing.T) {
+	eachRepository(
+		t, func(t *testing.T, r repository.TaskRepository) {
+			ctx := context.Background()
+			key := "cache-key"
+			source := createTask(t, r, &model.Task{Model: "a", CacheKey: &key})
+			outputs := []*model.TaskOutput{{Seq: 0, Kind: "image", MimeType: "image/png", StorageRef: "t_task.image1", Size: 3}}
+			if err := r.SaveResult(ctx, source.ID, [][]byte{[]byte("AAAA")}, outputs); err != nil {
+				t.Fatalf("save result: %v", err)
+			}
+			found, err := r.FindCached(ctx, key)
+			if err != nil || found.ID != source.ID {
+				t.Fatalf("find cached: got %v %v, want task %d", found, err, source.ID)
+			}
+
+			task := createTask(t, r, &model.Task{Model: "a", CacheKey: &key})
+			copied, err := r.CopyResult(ctx, task.ID, source.ID)
+			if err != nil || !copied {
+				t.Fatalf("copy result: got %v %v, want copied", copied, err)
+			}
+			got := getTask(t, r, task.ID)
+			if got.Status != int32(model.StatusSuccess) || got.CachedFrom == nil || *got.CachedFrom != source.ID {
+				t.Errorf("got status %d cached_from %v, want success from task %d", got.Status, got.CachedFrom, source.ID)
+			}
+			if got.Image1 == nil || string(*got.Image1) != "AAAA" {
+				t.Errorf("got image1 %v, want the image of the source task", got.Image1)
+			}
+			if listed, _ := r.ListOutputs(ctx, task.ID); len(listed) != 1 {
+				t.Errorf("got %d outputs, want 1", len(listed))
+			}
+			// 已经结束的任务不会被覆盖
+			if copied, err = r.CopyResult(ctx, task.ID, source.ID); err != nil || copied {
+				t.Errorf("copy to finished task: got %v %v, want not copied", copied, err)
+			}
+
+			if err = r.PurgeImages(ctx, []int64{source.ID, task.ID}); err != nil {
+				t.Fatalf("purge images: %v", err)
+			}
+			if _, err = r.FindCached(ctx, key); !errors.Is(err, gorm.ErrRecordNotFound) {
+				t.Errorf("find cached after purge: got %v, want ErrRecordNotFound", err)
+			}
+			pending := createTask(t, r, &model.Task{Model: "a", CacheKey: &key})
+			if copied, err = r.CopyResult(ctx, pending.ID, source.ID); err != nil || copied {
+				t.Errorf("copy purged result: got %v %v, want not copied", copied, err)
+			}
+		},
+	)
+}
+
+func TestCreateCached(t *testing.T) {
+	eachRepository(
+		t, func(t *testing.T, r repository.TaskRepository) {
+			ctx := context.Background()
+			cacheKey := "cache-key"
+			source := createTask(t, r, &model.Task{Model: "a", CacheKey: &cacheKey})
+			outputs := []*model.TaskOutput{{Seq: 0, Kind: "image", MimeType: "image/png", StorageRef: "t_task.image1", Size: 3}}
+			if err := r.SaveResult(ctx, source.ID, [][]byte{[]byte("AAAA")}, outputs); err != nil {
+				t.Fatalf("save result: %v", err)
+			}
+
+			task := &model.Task{Parameter: "{}", Model: "a", CacheKey: &cacheKey}
+			key := &model.IdempotencyKey{UserID: 1, IdempotencyKey: "key", RequestHash: "hash", ExpiresAt: time.Now().Add(time.Hour)}
+			created, err := r.CreateCached(ctx, task, nil, key, source.ID)
+			if err != nil || !created {
+				t.Fatalf("create cached: got %v %v, want created", created, err)
+			}
+			got := getTask(t, r, task.ID)
+			if got.Status != int32(model.StatusSuccess) || got.CachedFrom == nil || *got.CachedFrom != source.ID {
+				t.Errorf("got status %d cached_from %v, want success from task %d", got.Status, got.CachedFrom, source.ID)
+			}
+			if got.Image1 == nil || string(*got.Image1) != "AAAA" {
+				t.Errorf("got image1 %v, want the image of the source task", got.Image1)
+			}
+			if listed, _ := r.ListOutputs(ctx, task.ID); len(listed) != 1 {
+				t.Errorf("got %d outputs, want 1", len(listed))
+			}
+			if stored, err := r.GetKey(ctx, 1, "key"); err != nil || stored.TaskID != task.ID {
+				t.Errorf("get key: got %v %v, want task %d", stored, err, task.ID)
+			}
+			duplicate := &model.IdempotencyKey{UserID: 1, IdempotencyKey: "key", ExpiresAt: time.Now().Add(time.Hour)}
+			if _, err = r.CreateCached(ctx, &model.Task{Parameter: "{}", Model: "a"}, nil, duplicate, source.ID); err == nil {
+				t.Error("create cached with a duplicate key: got nil, want an error")
+			}
+
+			// 源任务的结果被清理后不创建任务
+			if err = r.PurgeImages(ctx, []int64{source.ID}); err != nil {
+				t.Fatalf("purge images: %v", err)
+			}
+			pending := &model.Task{Parameter: "{}", Model: "a"}
+			if created, err = r.CreateCached(ctx, pending, nil, nil, source.ID); err != nil || created || pending.ID != 0 {
+				t.Errorf("create from purged result: got %v %v id %d, want not created", created, err, pending.ID)
+			}
+		},
+	)
+}
+
+func TestDelete(t *testing.T) {
+	eachRepository(
+		t, func(t *testing.T, r repository.TaskRepository) {
+			ctx := context.Background()
+			task := &model.Task{Parameter: "{}", Model: "a"}
+			inputs := []*model.TaskInput{{Name: "init_image", ContentType: "image/png", Data: []byte("png")}}
+			if err := r.CreateWithInputs(ctx, task, inputs); err != nil {
+				t.Fatalf("create with inputs: %v", err)
+			}
+			outputs := []*model.TaskOutput{{Seq: 0, Kind: "image", MimeType: "image/png", StorageRef: "t_task.image1", Size: 3}}
+			if err := r.SaveResult(ctx, task.ID, [][]byte{[]byte("AAAA")}, outputs); err != nil {
+				t.Fatalf("save result: %v", err)
+			}
+			if err := r.Delete(ctx, []int64{task.ID}); err != nil {
+				t.Fatalf("delete: %v", err)
+			}
+			if _, err := r.Get(ctx, task.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+				t.Errorf("get deleted task: got %v, want ErrRecordNotFound", err)
+			}
+			if listed, _ := r.ListInputs(ctx, task.ID); len(listed) != 0 {
+				t.Errorf("got %d inputs, want 0", len(listed))
+			}
+			if listed, _ := r.ListOutputs(ctx, task.ID); len(listed) != 0 {
+				t.Errorf("got %d outputs, want 0", len(listed))
+			}
+		},
+	)
+}
+
+func TestPurgeImages(t *testing.T) {
+	eachRepository(
+		t, func(t *testing.T, r repository.TaskRepository) {
+			ctx := context.Background()
+			task := createTask(t, r, &model.Task{Model: "a"})
+			text := []byte("hello")
+			outputs := []*model.TaskOutput{{Seq: 0, Kind: "text", MimeType: "text/plain", StorageRef: "t_task_output.data", Size: 5, Data: &text}}
+			if err := r.SaveResult(ctx, task.ID, nil, outputs); err != nil {
+				t.Fatalf("save result: %v", err)
+			}
+			// 只有内联输出的任务也需要清理
+			listed, err := r.List(ctx, repository.ListOptions{HasImages: true})
+			if err != nil || len(listed) != 1 {
+				t.Fatalf("list tasks with contents: got %d tasks, %v, want 1", len(listed), err)
+			}
+			contents, err := r.ListOutputContents(ctx, []int64{task.ID})
+			if err != nil || len(contents) != 1 || contents[0].Data == nil || string(*contents[0].Data) != "hello" {
+				t.Fatalf("list output contents: got %+v, %v, want the text output", contents, err)
+			}
+
+			if err = r.PurgeImages(ctx, []int64{task.ID}); err != nil {
+				t.Fatalf("purge images: %v", err)
+			}
+			output, err := r.GetOutput(ctx, task.ID, 0)
+			if err != nil {
+				t.Fatalf("get output: %v", err)
+			}
+			if output.Data != nil || output.Size != 5 {
+				t.Errorf("got data %v size %d, want the content cleared and the size kept", output.Data, output.Size)
+			}
+			if listed, _ = r.List(ctx, repository.ListOptions{HasImages: true}); len(listed) != 0 {
+				t.Errorf("got %d tasks with contents after purge, want 0", len(listed))
+			}
+		},
+	)
+}
+
+func TestCountByStatus(t *testing.T) {
+	eachRepository(
+		t, func(t *testing.T, r repository.TaskRepository) {
+			ctx := context.Background()
+			createTask(t, r, &model.Task{Model: "a"})
+			createTask(t, r, &model.Task{Model: "a"})
+			createTask(t, r, &model.Task{Model: "b"})
+			createTask(t, r, &model.Task{Model: "b", Status: int32(model.StatusRunning)})
+			createTask(t, r, &model.Task{Model: "b", Status: int32(model.StatusSuccess)})
+
+			counts, err := r.CountByStatus(ctx, model.StatusInit, model.StatusRunning, model.StatusFail)
+			if err != nil {
+				t.Fatalf("count by status: %v", err)
+			}
+			if counts[model.StatusInit]["a"] != 2 || counts[model.StatusInit]["b"] != 1 || counts[model.StatusRunning]["b"] != 1 {
+				t.Errorf("got %v, want 2 init of a, 1 init and 1 running of b", counts)
+			}
+			if len(counts[model.StatusFail]) != 0 {
+				t.Errorf("got %v fail counts, want none", counts[model.StatusFail])
+			}
+			if _, ok := counts[model.StatusSuccess]; ok {
+				t.Error("got success counts, want only the requested statuses")
+			}
+		},
+	)
+}
@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"severless-task-scheduler/db/model"
+	"time"
+)
+
+// MaxImages 任务最多可以保存的图片数量，对应 t_task.image1 ~ image4
+const MaxImages = 4
+
+// ErrDuplicateKey 用户未过期的幂等键已存在
+var ErrDuplicateKey = errors.New("idempotency key already exists")
+
+// TaskRepository 任务的存储接口，记录不存在时返回 gorm.ErrRecordNotFound
+type TaskRepository interface {
+	// Create 创建任务，成功后回填 ID 等字段
+	Create(ctx context.Context, task *model.Task) error
+	// CreateWithInputs 在同一个事务中创建任务和它的输入文件，成功后回填输入文件的 TaskID
+	CreateWithInputs(ctx context.Context, task *model.Task, inputs []*model.TaskInput) error
+	// CreateWithKey 在同一个事务中创建任务、输入文件和幂等键，成功后回填幂等键的 TaskID
+	// 同一用户未过期的幂等键已存在时不创建任务，返回 ErrDuplicateKey 或数据库的唯一索引错误
+	CreateWithKey(ctx context.Context, task *model.Task, inputs []*model.TaskInput, key *model.IdempotencyKey) error
+	// CreateCached 在同一个事务中创建已经成功的任务并复制任务 sourceID 的结果，key 为 nil 时不创建幂等键
+	// 源任务的结果已被清理时不创建任务并返回 false，幂等键已存在时与 CreateWithKey 相同
+	CreateCached(ctx context.Context, task *model.Task, inputs []*model.TaskInput, key *model.IdempotencyKey, sourceID int64) (bool, error)
+	// GetKey 获取用户未过期的幂等键
+	GetKey(ctx context.Context, userID int64, key string) (*model.IdempotencyKey, error)
+	// DeleteExpiredKeys 删除在 before 之前过期的幂等键，返回删除的数量
+	DeleteExpiredKeys(ctx context.Context, before time.Time) (int64, error)
+	// ListInputs 获取任务的输入文件
+	ListInputs(ctx context.Context, taskID int64) ([]*model.TaskInput, error)
+	// Get 根据 ID 获取任务
+	Get(ctx context.Context, id int64) (*model.Task, error)
+	// Claim 认领最多 limit 个待执行的任务，将其状态置为执行中并增加尝试次数
+	// quotas 限制每个模型最多认领的任务数，不在其中的模型不限制
+	Claim(ctx context.Context, limit int, quotas map[string]int) ([]*model.Task, error)
+	// UpdateStatus 更新任务状态，message 为 nil 时不修改
+	UpdateStatus(ctx context.Context, id int64, status model.Status, message *string) error
+	// SubmitJob 记录异步后端返回的任务 ID，nextPollAt 之后开始轮询结果
+	SubmitJob(ctx context.Context, id int64, jobID string, nextPollAt time.Time) error
+	// ClaimJobs 认领最多 limit 个到期需要轮询的异步任务，lease 内不会被再次认领
+	ClaimJobs(ctx context.Context, limit int, lease time.Duration) ([]*model.Task, error)
+	// DeferJob 把异步任务的下一次轮询推迟到 nextPollAt
+	DeferJob(ctx context.Context, id int64, nextPollAt time.Time) error
+	// SaveResult 保存生成结果，并将任务状态置为成功。images 写入图片字段，outputs 记录所有输出的类型和位置
+	SaveResult(ctx context.Context, id int64, images [][]byte, outputs []*model.TaskOutput) error
+	// ListOutputs 获取任务的输出，不加载输出内容
+	ListOutputs(ctx context.Context, taskID int64) ([]*model.TaskOutput, error)
+	// GetOutput 获取任务的第 seq 个输出及其内容
+	GetOutput(ctx context.Context, taskID int64, seq int32) (*model.TaskOutput, error)
+	// ListOutputContents 获取多个任务的输出及其内容，按任务 ID 和序号排列，用于归档
+	ListOutputContents(ctx context.Context, taskIDs []int64) ([]*model.TaskOutput, error)
+	// FindCached 查找缓存键相同的成功任务，结果已被清理的任务不会被找到
+	FindCached(ctx context.Context, cacheKey string) (*model.Task, error)
+	// CopyResult 把任务 sourceID 的结果复制到尚未结束的任务 id，并将其状态置为成功
+	// 源任务的结果已被清理或任务 id 已经结束时返回 false
+	CopyResult(ctx context.Context, id int64, sourceID int64) (bool, error)
+	// SetCacheKey 更新任务的缓存键
+	SetCacheKey(ctx context.Context, id int64, cacheKey *string) error
+	// List 按条件列出任务，按 ID 升序排列
+	List(ctx context.Context, options ListOptions) ([]*model.Task, error)
+	// CountByStatus 在一次查询中统计指定的各个状态下每个模型的任务数
+	CountByStatus(ctx context.Context, statuses ...model.Status) (map[model.Status]map[string]int64, error)
+	// PurgeImages 清空任务的图片字段、缓存键和保存在 t_task_output.data 中的输出内容，并删除输入文件
+	PurgeImages(ctx context.Context, ids []int64) error
+	// Delete 删除任务及其输入文件和输出
+	Delete(ctx context.Context, ids []int64) error
+}
+
+// exhaustedModels 配额已用完的模型
+func exhaustedModels(quotas map[string]int) []string {
+	names := make([]string, 0)
+	for name, quota := range quotas {
+		if quota <= 0 {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// takeQuota 扣减模型的配额，配额不足时返回 false
+func takeQuota(quotas map[string]int, name string) bool {
+	quota, ok := quotas[name]
+	if !ok {
+		return true
+	}
+	if quota <= 0 {
+		return false
+	}
+	quotas[name] = quota - 1
+	return true
+}
+
+type statusCount struct {
+	Status int32
+	Model  string
+	Count  int64
+}
+
+// groupCounts 把按状态和模型分组的计数整理为嵌套的 map，没有任务的状态为空 map
+func groupCounts(statuses []model.Status, rows []statusCount) map[model.Status]map[string]int64 {
+	counts := make(map[model.Status]map[string]int64, len(statuses))
+	for _, status := range statuses {
+		counts[status] = make(map[string]int64)
+	}
+	for _, row := range rows {
+		if count, ok := counts[model.Status(row.Status)]; ok {
+			count[row.Model] += row.Count
+		}
+	}
+	return counts
+}
+
+type ListOptions struct {
+	Status *model.Status
+	// AfterID 只返回 ID 大于该值的任务，用于分批遍历
+	AfterID int64
+	// CreatedBefore 只返回在该时间之前创建的任务
+	CreatedBefore *time.Time
+	// HasImages 只返回保存了图片或 t_task_output.data 中有输出内容的任务
+	HasImages bool
+	// WithImages 是否加载图片字段，默认不加载
+	WithImages bool
+	Limit      int
+}
+
+// imageColumns 把图片依次填入任务的图片字段，超出 MaxImages 的部分会被丢弃
+func imageColumns(task *model.Task, images [][]byte) {
+	slots := []**[]byte{&task.Image1, &task.Image2, &task.Image3, &task.Image4}
+	for i, image := range images {
+		if i >= MaxImages {
+			break
+		}
+		image := image
+		*slots[i] = &image
+	}
+}
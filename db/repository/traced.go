@@ -0,0 +1,209 @@
+package repository
+
+import (
+	"context"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"severless-task-scheduler/db/model"
+	"severless-task-scheduler/tracing"
+	"time"
+)
+
+type tracedTaskRepository struct {
+	next TaskRepository
+}
+
+// WithTracing 为每次存储操作创建一个 span
+func WithTracing(next TaskRepository) TaskRepository {
+	return &tracedTaskRepository{next: next}
+}
+
+func start(ctx context.Context, operation string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracing.Tracer.Start(
+		ctx, "db."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(append(attributes, attribute.String("db.sql.table", model.TableNameTask))...),
+	)
+}
+
+func (r *tracedTaskRepository) Create(ctx context.Context, task *model.Task) error {
+	ctx, span := start(ctx, "Create")
+	err := r.next.Create(ctx, task)
+	span.SetAttributes(attribute.Int64("task.id", task.ID))
+	tracing.End(span, err)
+	return err
+}
+
+func (r *tracedTaskRepository) CreateWithInputs(ctx context.Context, task *model.Task, inputs []*model.TaskInput) error {
+	ctx, span := start(ctx, "CreateWithInputs", attribute.Int("inputs", len(inputs)))
+	err := r.next.CreateWithInputs(ctx, task, inputs)
+	span.SetAttributes(attribute.Int64("task.id", task.ID))
+	tracing.End(span, err)
+	return err
+}
+
+func (r *tracedTaskRepository) CreateWithKey(ctx context.Context, task *model.Task, inputs []*model.TaskInput, key *model.IdempotencyKey) error {
+	ctx, span := start(ctx, "CreateWithKey", attribute.Int("inputs", len(inputs)))
+	err := r.next.CreateWithKey(ctx, task, inputs, key)
+	span.SetAttributes(attribute.Int64("task.id", task.ID))
+	tracing.End(span, err)
+	return err
+}
+
+func (r *tracedTaskRepository) CreateCached(
+	ctx context.Context, task *model.Task, inputs []*model.TaskInput, key *model.IdempotencyKey, sourceID int64,
+) (bool, error) {
+	ctx, span := start(ctx, "CreateCached", attribute.Int("inputs", len(inputs)), attribute.Int64("task.source_id", sourceID))
+	created, err := r.next.CreateCached(ctx, task, inputs, key, sourceID)
+	span.SetAttributes(attribute.Int64("task.id", task.ID), attribute.Bool("created", created))
+	tracing.End(span, err)
+	return created, err
+}
+
+func (r *tracedTaskRepository) GetKey(ctx context.Context, userID int64, key string) (*model.IdempotencyKey, error) {
+	ctx, span := start(ctx, "GetKey", attribute.Int64("user.id", userID))
+	existing, err := r.next.GetKey(ctx, userID, key)
+	tracing.End(span, err)
+	return existing, err
+}
+
+func (r *tracedTaskRepository) DeleteExpiredKeys(ctx context.Context, before time.Time) (int64, error) {
+	ctx, span := start(ctx, "DeleteExpiredKeys")
+	deleted, err := r.next.DeleteExpiredKeys(ctx, before)
+	span.SetAttributes(attribute.Int64("deleted", deleted))
+	tracing.End(span, err)
+	return deleted, err
+}
+
+func (r *tracedTaskRepository) ListInputs(ctx context.Context, taskID int64) ([]*model.TaskInput, error) {
+	ctx, span := start(ctx, "ListInputs", attribute.Int64("task.id", taskID))
+	inputs, err := r.next.ListInputs(ctx, taskID)
+	tracing.End(span, err)
+	return inputs, err
+}
+
+func (r *tracedTaskRepository) Get(ctx context.Context, id int64) (*model.Task, error) {
+	ctx, span := start(ctx, "Get", attribute.Int64("task.id", id))
+	task, err := r.next.Get(ctx, id)
+	tracing.End(span, err)
+	return task, err
+}
+
+func (r *tracedTaskRepository) Claim(ctx context.Context, limit int, quotas map[string]int) ([]*model.Task, error) {
+	ctx, span := start(ctx, "Claim", attribute.Int("limit", limit))
+	tasks, err := r.next.Claim(ctx, limit, quotas)
+	span.SetAttributes(attribute.Int("claimed", len(tasks)))
+	tracing.End(span, err)
+	return tasks, err
+}
+
+func (r *tracedTaskRepository) UpdateStatus(ctx context.Context, id int64, status model.Status, message *string) error {
+	ctx, span := start(ctx, "UpdateStatus", attribute.Int64("task.id", id), attribute.String("task.status", status.String()))
+	err := r.next.UpdateStatus(ctx, id, status, message)
+	tracing.End(span, err)
+	return err
+}
+
+func (r *tracedTaskRepository) SubmitJob(ctx context.Context, id int64, jobID string, nextPollAt time.Time) error {
+	ctx, span := start(ctx, "SubmitJob", attribute.Int64("task.id", id))
+	err := r.next.SubmitJob(ctx, id, jobID, nextPollAt)
+	tracing.End(span, err)
+	return err
+}
+
+func (r *tracedTaskRepository) ClaimJobs(ctx context.Context, limit int, lease time.Duration) ([]*model.Task, error) {
+	ctx, span := start(ctx, "ClaimJobs", attribute.Int("limit", limit))
+	tasks, err := r.next.ClaimJobs(ctx, limit, lease)
+	span.SetAttributes(attribute.Int("claimed", len(tasks)))
+	tracing.End(span, err)
+	return tasks, err
+}
+
+func (r *tracedTaskRepository) DeferJob(ctx context.Context, id int64, nextPollAt time.Time) error {
+	ctx, span := start(ctx, "DeferJob", attribute.Int64("task.id", id))
+	err := r.next.DeferJob(ctx, id, nextPollAt)
+	tracing.End(span, err)
+	return err
+}
+
+func (r *tracedTaskRepository) SaveResult(ctx context.Context, id int64, images [][]byte, outputs []*model.TaskOutput) error {
+	ctx, span := start(ctx, "SaveResult", attribute.Int64("task.id", id), attribute.Int("images", len(images)), attribute.Int("outputs", len(outputs)))
+	err := r.next.SaveResult(ctx, id, images, outputs)
+	tracing.End(span, err)
+	return err
+}
+
+func (r *tracedTaskRepository) ListOutputs(ctx context.Context, taskID int64) ([]*model.TaskOutput, error) {
+	ctx, span := start(ctx, "ListOutputs", attribute.Int64("task.id", taskID))
+	outputs, err := r.next.ListOutputs(ctx, taskID)
+	tracing.End(span, err)
+	return outputs, err
+}
+
+func (r *tracedTaskRepository) GetOutput(ctx context.Context, taskID int64, seq int32) (*model.TaskOutput, error) {
+	ctx, span := start(ctx, "GetOutput", attribute.Int64("task.id", taskID), attribute.Int("output.seq", int(seq)))
+	output, err := r.next.GetOutput(ctx, taskID, seq)
+	tracing.End(span, err)
+	return output, err
+}
+
+func (r *tracedTaskRepository) ListOutputContents(ctx context.Context, taskIDs []int64) ([]*model.TaskOutput, error) {
+	ctx, span := start(ctx, "ListOutputContents", attribute.Int("tasks", len(taskIDs)))
+	outputs, err := r.next.ListOutputContents(ctx, taskIDs)
+	tracing.End(span, err)
+	return outputs, err
+}
+
+func (r *tracedTaskRepository) FindCached(ctx context.Context, cacheKey string) (*model.Task, error) {
+	ctx, span := start(ctx, "FindCached")
+	task, err := r.next.FindCached(ctx, cacheKey)
+	tracing.End(span, err)
+	return task, err
+}
+
+func (r *tracedTaskRepository) CopyResult(ctx context.Context, id int64, sourceID int64) (bool, error) {
+	ctx, span := start(ctx, "CopyResult", attribute.Int64("task.id", id), attribute.Int64("task.source_id", sourceID))
+	copied, err := r.next.CopyResult(ctx, id, sourceID)
+	span.SetAttributes(attribute.Bool("copied", copied))
+	tracing.End(span, err)
+	return copied, err
+}
+
+func (r *tracedTaskRepository) SetCacheKey(ctx context.Context, id int64, cacheKey *string) error {
+	ctx, span := start(ctx, "SetCacheKey", attribute.Int64("task.id", id))
+	err := r.next.SetCacheKey(ctx, id, cacheKey)
+	tracing.End(span, err)
+	return err
+}
+
+func (r *tracedTaskRepository) List(ctx context.Context, options ListOptions) ([]*model.Task, error) {
+	ctx, span := start(ctx, "List", attribute.Int("limit", options.Limit))
+	tasks, err := r.next.List(ctx, options)
+	tracing.End(span, err)
+	return tasks, err
+}
+
+func (r *tracedTaskRepository) CountByStatus(ctx context.Context, statuses ...model.Status) (map[model.Status]map[string]int64, error) {
+	names := make([]string, 0, len(statuses))
+	for _, status := range statuses {
+		names = append(names, status.String())
+	}
+	ctx, span := start(ctx, "CountByStatus", attribute.StringSlice("task.status", names))
+	counts, err := r.next.CountByStatus(ctx, statuses...)
+	tracing.End(span, err)
+	return counts, err
+}
+
+func (r *tracedTaskRepository) PurgeImages(ctx context.Context, ids []int64) error {
+	ctx, span := start(ctx, "PurgeImages", attribute.Int("tasks", len(ids)))
+	err := r.next.PurgeImages(ctx, ids)
+	tracing.End(span, err)
+	return err
+}
+
+func (r *tracedTaskRepository) Delete(ctx context.Context, ids []int64) error {
+	ctx, span := start(ctx, "Delete", attribute.Int("tasks", len(ids)))
+	err := r.next.Delete(ctx, ids)
+	tracing.End(span, err)
+	return err
+}
@@ -0,0 +1,58 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"time"
+)
+
+// GormLogger 把 gorm 的日志输出到 logrus，SQL 语句只在 debug 级别输出，慢查询以 warn 级别输出
+type GormLogger struct {
+	SlowThreshold time.Duration
+}
+
+// NewGormLogger 耗时超过 slowThreshold 的 SQL 记为慢查询，0 表示不记录慢查询
+func NewGormLogger(slowThreshold time.Duration) *GormLogger {
+	return &GormLogger{SlowThreshold: slowThreshold}
+}
+
+// LogMode 日志级别由 logrus 控制，这里保持不变
+func (l *GormLogger) LogMode(logger.LogLevel) logger.Interface {
+	return l
+}
+
+func (l *GormLogger) Info(ctx context.Context, message string, args ...any) {
+	FromContext(ctx).Infof(message, args...)
+}
+
+func (l *GormLogger) Warn(ctx context.Context, message string, args ...any) {
+	FromContext(ctx).Warnf(message, args...)
+}
+
+func (l *GormLogger) Error(ctx context.Context, message string, args ...any) {
+	FromContext(ctx).Errorf(message, args...)
+}
+
+func (l *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	elapsed := time.Since(begin)
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+		sql, rows := fc()
+		FromContext(ctx).WithFields(
+			logrus.Fields{"sql": sql, "rows": rows, "elapsed": elapsed.String()},
+		).WithError(err).Error("sql error")
+	case l.SlowThreshold > 0 && elapsed > l.SlowThreshold:
+		sql, rows := fc()
+		FromContext(ctx).WithFields(
+			logrus.Fields{"sql": sql, "rows": rows, "elapsed": elapsed.String()},
+		).Warn("slow sql")
+	case logrus.IsLevelEnabled(logrus.DebugLevel):
+		sql, rows := fc()
+		FromContext(ctx).WithFields(
+			logrus.Fields{"sql": sql, "rows": rows, "elapsed": elapsed.String()},
+		).Debug("sql")
+	}
+}
@@ -0,0 +1,74 @@
+package logging
+
+import (
+	"context"
+	"github.com/sirupsen/logrus"
+	"severless-task-scheduler/config"
+	"strings"
+)
+
+// 日志中统一使用的字段名
+const (
+	FieldRequestID = "request_id"
+	FieldTaskID    = "task_id"
+	FieldModel     = "model"
+	FieldUserID    = "user_id"
+	FieldAttempt   = "attempt"
+)
+
+type fieldsKey struct{}
+
+// Setup 配置全局 logrus，format 为 json 或 text，level 为 logrus 支持的级别名称
+func Setup(log config.LogConfig) {
+	if strings.EqualFold(log.Format, "text") {
+		logrus.SetFormatter(&logrus.TextFormatter{FullTimestamp: true})
+	} else {
+		logrus.SetFormatter(&logrus.JSONFormatter{})
+	}
+	level, err := logrus.ParseLevel(log.Level)
+	if err != nil {
+		level = logrus.InfoLevel
+		logrus.Warnf("log level %s is invalid, use %s", log.Level, level)
+	}
+	logrus.SetLevel(level)
+}
+
+// WithFields 返回携带日志字段的 ctx，字段会与 ctx 中已有的字段合并
+func WithFields(ctx context.Context, fields logrus.Fields) context.Context {
+	merged := logrus.Fields{}
+	if existing, ok := ctx.Value(fieldsKey{}).(logrus.Fields); ok {
+		for k, v := range existing {
+			merged[k] = v
+		}
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, fieldsKey{}, merged)
+}
+
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return WithFields(ctx, logrus.Fields{FieldRequestID: requestID})
+}
+
+// RequestID 返回 ctx 中的请求 ID，没有时返回空字符串
+func RequestID(ctx context.Context) string {
+	fields, _ := ctx.Value(fieldsKey{}).(logrus.Fields)
+	requestID, _ := fields[FieldRequestID].(string)
+	return requestID
+}
+
+// Fields 返回 ctx 中的日志字段
+func Fields(ctx context.Context) logrus.Fields {
+	fields, _ := ctx.Value(fieldsKey{}).(logrus.Fields)
+	return fields
+}
+
+// FromContext 返回带有 ctx 中日志字段的 logger
+func FromContext(ctx context.Context) *logrus.Entry {
+	entry := logrus.NewEntry(logrus.StandardLogger())
+	if fields, ok := ctx.Value(fieldsKey{}).(logrus.Fields); ok {
+		entry = entry.WithFields(fields)
+	}
+	return entry
+}
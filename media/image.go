@@ -0,0 +1,56 @@
+package media
+
+import (
+	"encoding/base64"
+	"net/http"
+	"strings"
+)
+
+// extensions 常见图片类型的文件扩展名
+var extensions = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/webp": ".webp",
+	"image/gif":  ".gif",
+	"image/bmp":  ".bmp",
+}
+
+// SplitDataURL 取出 data url 的 MIME 类型和 base64 内容，不是 data url 时 MIME 类型为空
+func SplitDataURL(encoded string) (string, string) {
+	if !strings.HasPrefix(encoded, "data:") {
+		return "", encoded
+	}
+	header, payload, ok := strings.Cut(strings.TrimPrefix(encoded, "data:"), ",")
+	if !ok {
+		return "", encoded
+	}
+	return strings.TrimSuffix(header, ";base64"), payload
+}
+
+// DecodeImage 解码图片字段中的 base64 图片，允许带 data url 前缀
+func DecodeImage(encoded []byte) ([]byte, error) {
+	_, payload := SplitDataURL(string(encoded))
+	return base64.StdEncoding.DecodeString(payload)
+}
+
+// ImageMimeType 图片的 MIME 类型，优先使用 data url 中声明的类型，无法识别时按 png 处理
+func ImageMimeType(encoded string) string {
+	if mimeType, _ := SplitDataURL(encoded); mimeType != "" {
+		return mimeType
+	}
+	data, err := DecodeImage([]byte(encoded))
+	if err == nil {
+		if contentType := http.DetectContentType(data); strings.HasPrefix(contentType, "image/") {
+			return contentType
+		}
+	}
+	return "image/png"
+}
+
+// Extension MIME 类型对应的文件扩展名，未知的类型返回 .bin
+func Extension(mimeType string) string {
+	if extension, ok := extensions[mimeType]; ok {
+		return extension
+	}
+	return ".bin"
+}
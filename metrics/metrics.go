@@ -0,0 +1,131 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/sirupsen/logrus"
+	"severless-task-scheduler/db/model"
+)
+
+const namespace = "task_scheduler"
+
+// 任务失败原因，作为 task_failures_total 的 reason 标签
+const (
+	ReasonInvalidParameter = "invalid_parameter"
+	ReasonInput            = "input"
+	ReasonModelNotFound    = "model_not_found"
+	ReasonNoConnection     = "no_connection"
+	ReasonWrite            = "write"
+	ReasonRead             = "read"
+	ReasonStatus           = "status"
+	ReasonDecode           = "decode"
+	ReasonPersist          = "persist"
+	ReasonTimeout          = "timeout"
+	ReasonJobFailed        = "job_failed"
+)
+
+// 复用缓存结果的阶段，作为 cache_hits_total 的 stage 标签
+const (
+	StageCreate   = "create"
+	StageDispatch = "dispatch"
+)
+
+var (
+	QueueWait = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "queue_wait_seconds",
+			Help:      "Time from task creation to dispatch.",
+			Buckets:   prometheus.ExponentialBuckets(0.5, 2, 14),
+		},
+		[]string{"model"},
+	)
+	GenerationTime = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "generation_seconds",
+			Help:      "Time from dispatch to task completion.",
+			Buckets:   prometheus.ExponentialBuckets(0.5, 2, 12),
+		},
+		[]string{"model", "status"},
+	)
+	TaskFailures = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "task_failures_total",
+			Help:      "Failed tasks by reason.",
+		},
+		[]string{"model", "reason"},
+	)
+	WebsocketReconnects = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "websocket_reconnects_total",
+			Help:      "Successful reconnections to model backends.",
+		},
+		[]string{"model"},
+	)
+	PingFailures = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "websocket_ping_failures_total",
+			Help:      "Failed heartbeat pings to model backends.",
+		},
+		[]string{"model"},
+	)
+	CacheHits = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "cache_hits_total",
+			Help:      "Tasks that reused the result of an identical successful task.",
+		},
+		[]string{"model", "stage"},
+	)
+	InflightCalls = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "inflight_calls",
+			Help:      "Model calls currently waiting for a backend.",
+		},
+		[]string{"model"},
+	)
+)
+
+// TaskCounter 统计各状态下每个模型的任务数，由 repository.TaskRepository 实现
+type TaskCounter interface {
+	CountByStatus(statuses ...model.Status) (map[model.Status]map[string]int64, error)
+}
+
+var tasksDesc = prometheus.NewDesc(
+	prometheus.BuildFQName(namespace, "", "tasks"),
+	"Tasks in the database by status and model.",
+	[]string{"status", "model"},
+	nil,
+)
+
+// taskCollector 在每次抓取时用一次分组查询统计所有状态的任务数，避免多个实例各自维护计数
+type taskCollector struct {
+	counter TaskCounter
+}
+
+// RegisterTaskCollector 注册按状态和模型统计任务数的指标
+func RegisterTaskCollector(counter TaskCounter) {
+	prometheus.MustRegister(&taskCollector{counter: counter})
+}
+
+func (c *taskCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- tasksDesc
+}
+
+func (c *taskCollector) Collect(ch chan<- prometheus.Metric) {
+	counts, err := c.counter.CountByStatus(model.Statuses...)
+	if err != nil {
+		logrus.Errorf("count tasks error: %v", err)
+		return
+	}
+	for status, count := range counts {
+		for modelName, n := range count {
+			ch <- prometheus.MustNewConstMetric(tasksDesc, prometheus.GaugeValue, float64(n), status.String(), modelName)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package retention
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"severless-task-scheduler/db/model"
+	"severless-task-scheduler/media"
+	"time"
+)
+
+// ArchivedTask 归档文件中的一行，图片和输出内容保存为单独的文件
+type ArchivedTask struct {
+	model.Task
+	Action     string           `json:"action"`
+	ArchivedAt time.Time        `json:"archived_at"`
+	ImageFiles []string         `json:"image_files,omitempty"`
+	Outputs    []ArchivedOutput `json:"outputs,omitempty"`
+}
+
+// ArchivedOutput 任务的一个输出，File 为保存在 t_task_output.data 中的内容导出的文件
+type ArchivedOutput struct {
+	model.TaskOutput
+	File string `json:"file,omitempty"`
+}
+
+// Archiver 把任务追加到 <dir>/tasks-<date>.jsonl，图片解码后写入 <dir>/images/<id>_<n>.<ext>，
+// 输出内容写入 <dir>/outputs/<id>_<seq>.bin
+type Archiver struct {
+	dir  string
+	file *os.File
+}
+
+func NewArchiver(dir string, now time.Time) (*Archiver, error) {
+	for _, sub := range []string{"images", "outputs"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o755); err != nil {
+			return nil, err
+		}
+	}
+	file, err := os.OpenFile(
+		filepath.Join(dir, fmt.Sprintf("tasks-%s.jsonl", now.Format("20060102"))),
+		os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &Archiver{dir: dir, file: file}, nil
+}
+
+// Archive 归档任务及其输出，outputs 为这些任务的输出及其内容
+func (a *Archiver) Archive(tasks []*model.Task, outputs []*model.TaskOutput, action string) error {
+	now := time.Now()
+	taskOutputs := make(map[int64][]*model.TaskOutput)
+	for _, output := range outputs {
+		taskOutputs[output.TaskID] = append(taskOutputs[output.TaskID], output)
+	}
+	for _, task := range tasks {
+		archived := ArchivedTask{Task: *task, Action: action, ArchivedAt: now}
+		for i, image := range []*[]byte{task.Image1, task.Image2, task.Image3, task.Image4} {
+			if image == nil {
+				continue
+			}
+			// 图片字段保存的是 base64，解码后按图片类型命名，无法解码时保留原文
+			data, extension := *image, ".bin"
+			if decoded, err := media.DecodeImage(*image); err == nil {
+				data, extension = decoded, media.Extension(media.ImageMimeType(string(*image)))
+			}
+			name := filepath.Join("images", fmt.Sprintf("%d_%d%s", task.ID, i+1, extension))
+			if err := os.WriteFile(filepath.Join(a.dir, name), data, 0o644); err != nil {
+				return err
+			}
+			archived.ImageFiles = append(archived.ImageFiles, name)
+		}
+		archived.Image1, archived.Image2, archived.Image3, archived.Image4 = nil, nil, nil, nil
+		for _, output := range taskOutputs[task.ID] {
+			archivedOutput := ArchivedOutput{TaskOutput: *output}
+			if output.Data != nil {
+				name := filepath.Join("outputs", fmt.Sprintf("%d_%d.bin", task.ID, output.Seq))
+				if err := os.WriteFile(filepath.Join(a.dir, name), *output.Data, 0o644); err != nil {
+					return err
+				}
+				archivedOutput.Data = nil
+				archivedOutput.File = name
+			}
+			archived.Outputs = append(archived.Outputs, archivedOutput)
+		}
+		line, err := json.Marshal(archived)
+		if err != nil {
+			return err
+		}
+		if _, err = a.file.Write(append(line, '\n')); err != nil {
+			return err
+		}
+	}
+	return a.file.Sync()
+}
+
+func (a *Archiver) Close() error {
+	return a.file.Close()
+}
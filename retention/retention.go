@@ -0,0 +1,181 @@
+package retention
+
+import (
+	"context"
+	"severless-task-scheduler/config"
+	"severless-task-scheduler/db/model"
+	"severless-task-scheduler/db/repository"
+	"time"
+)
+
+const (
+	DefaultBatchSize = 500
+
+	ActionPurgeImages = "purge_images"
+	ActionDelete      = "delete"
+)
+
+// Policy 某个状态的任务的保留策略，TTL 为 0 表示不处理
+type Policy struct {
+	Status model.Status
+	// PurgeImagesAfter 任务创建多久后清空图片和保存在数据库中的输出内容
+	PurgeImagesAfter time.Duration
+	// DeleteAfter 任务创建多久后删除
+	DeleteAfter time.Duration
+}
+
+type Config struct {
+	Policies []Policy
+	// ArchiveDir 不为空时，任务在清理前会被导出到该目录
+	ArchiveDir string
+	BatchSize  int
+	// MaxBatches 单次运行最多处理的批次数，0 表示不限制，用于控制 serverless 函数的执行时间
+	MaxBatches int
+}
+
+// ConfigFrom 把配置文件中的保留策略转换为运行配置，TTL 为 0 表示不处理
+func ConfigFrom(retention config.RetentionConfig) Config {
+	c := Config{
+		ArchiveDir: retention.ArchiveDir,
+		BatchSize:  retention.BatchSize,
+		MaxBatches: retention.MaxBatches,
+	}
+	for _, policy := range []Policy{
+		{
+			Status:           model.StatusSuccess,
+			PurgeImagesAfter: retention.SuccessImageTTL.Duration(),
+			DeleteAfter:      retention.SuccessDeleteTTL.Duration(),
+		},
+		{
+			Status:           model.StatusFail,
+			PurgeImagesAfter: retention.FailImageTTL.Duration(),
+			DeleteAfter:      retention.FailDeleteTTL.Duration(),
+		},
+	} {
+		if policy.PurgeImagesAfter > 0 || policy.DeleteAfter > 0 {
+			c.Policies = append(c.Policies, policy)
+		}
+	}
+	return c
+}
+
+// Report 一次运行的处理结果，Done 为 false 表示达到 MaxBatches 后仍有待处理的任务
+type Report struct {
+	Purged   int64 `json:"purged"`
+	Deleted  int64 `json:"deleted"`
+	Archived int64 `json:"archived"`
+	// ExpiredKeys 删除的过期幂等键数量
+	ExpiredKeys int64 `json:"expired_keys"`
+	Batches     int   `json:"batches"`
+	Done        bool  `json:"done"`
+}
+
+type Runner struct {
+	repo   repository.TaskRepository
+	config Config
+}
+
+func NewRunner(repo repository.TaskRepository, config Config) *Runner {
+	if config.BatchSize <= 0 {
+		config.BatchSize = DefaultBatchSize
+	}
+	return &Runner{repo: repo, config: config}
+}
+
+// Run 按策略分批清理任务。每一批处理完后即生效，中断后重新运行会从剩余的任务继续
+func (r *Runner) Run(ctx context.Context) (Report, error) {
+	report := Report{}
+	now := time.Now()
+	var archiver *Archiver
+	if r.config.ArchiveDir != "" {
+		var err error
+		archiver, err = NewArchiver(r.config.ArchiveDir, now)
+		if err != nil {
+			return report, err
+		}
+		defer archiver.Close()
+	}
+
+	expired, err := r.repo.DeleteExpiredKeys(ctx, now)
+	if err != nil {
+		return report, err
+	}
+	report.ExpiredKeys = expired
+
+	// 先删除再清理图片，避免即将被删除的任务先被归档一次图片
+	for _, policy := range r.config.Policies {
+		if policy.DeleteAfter > 0 {
+			done, err := r.run(ctx, &report, archiver, policy.Status, now.Add(-policy.DeleteAfter), ActionDelete)
+			if err != nil || !done {
+				return report, err
+			}
+		}
+		if policy.PurgeImagesAfter > 0 {
+			done, err := r.run(ctx, &report, archiver, policy.Status, now.Add(-policy.PurgeImagesAfter), ActionPurgeImages)
+			if err != nil || !done {
+				return report, err
+			}
+		}
+	}
+	report.Done = true
+	return report, nil
+}
+
+func (r *Runner) run(
+	ctx context.Context, report *Report, archiver *Archiver, status model.Status, before time.Time, action string,
+) (bool, error) {
+	var afterID int64
+	for {
+		if r.config.MaxBatches > 0 && report.Batches >= r.config.MaxBatches {
+			return false, nil
+		}
+		if err := ctx.Err(); err != nil {
+			return false, err
+		}
+		tasks, err := r.repo.List(
+			ctx,
+			repository.ListOptions{
+				Status:        &status,
+				AfterID:       afterID,
+				CreatedBefore: &before,
+				HasImages:     action == ActionPurgeImages,
+				WithImages:    archiver != nil,
+				Limit:         r.config.BatchSize,
+			},
+		)
+		if err != nil {
+			return false, err
+		}
+		if len(tasks) == 0 {
+			return true, nil
+		}
+
+		ids := make([]int64, 0, len(tasks))
+		for _, task := range tasks {
+			ids = append(ids, task.ID)
+		}
+		if archiver != nil {
+			outputs, err := r.repo.ListOutputContents(ctx, ids)
+			if err != nil {
+				return false, err
+			}
+			if err = archiver.Archive(tasks, outputs, action); err != nil {
+				return false, err
+			}
+			report.Archived += int64(len(tasks))
+		}
+		switch action {
+		case ActionDelete:
+			err = r.repo.Delete(ctx, ids)
+			report.Deleted += int64(len(ids))
+		case ActionPurgeImages:
+			err = r.repo.PurgeImages(ctx, ids)
+			report.Purged += int64(len(ids))
+		}
+		if err != nil {
+			return false, err
+		}
+		report.Batches++
+		afterID = ids[len(ids)-1]
+	}
+}
@@ -0,0 +1,80 @@
+package retention_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"severless-task-scheduler/db/model"
+	"severless-task-scheduler/db/repository"
+	"severless-task-scheduler/retention"
+	"testing"
+	"time"
+)
+
+func TestRunArchivesOutputs(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryTaskRepository()
+	task := &model.Task{Model: "a", Parameter: `{"model":"a"}`}
+	if err := repo.Create(ctx, task); err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	text := []byte("hello")
+	outputs := []*model.TaskOutput{
+		{Seq: 0, Kind: "image", MimeType: "image/png", StorageRef: "t_task.image1", Size: 4},
+		{Seq: 1, Kind: "text", MimeType: "text/plain", StorageRef: "t_task_output.data", Size: 5, Data: &text},
+	}
+	png := []byte("\x89PNG\r\n\x1a\n0000")
+	image := []byte("data:image/png;base64," + base64.StdEncoding.EncodeToString(png))
+	if err := repo.SaveResult(ctx, task.ID, [][]byte{image}, outputs); err != nil {
+		t.Fatalf("save result: %v", err)
+	}
+	time.Sleep(time.Millisecond)
+
+	dir := t.TempDir()
+	runner := retention.NewRunner(
+		repo, retention.Config{
+			Policies:   []retention.Policy{{Status: model.StatusSuccess, PurgeImagesAfter: time.Nanosecond}},
+			ArchiveDir: dir,
+		},
+	)
+	report, err := runner.Run(ctx)
+	if err != nil || report.Purged != 1 || report.Archived != 1 {
+		t.Fatalf("got report %+v, %v, want one task archived and purged", report, err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "tasks-*.jsonl"))
+	if len(files) != 1 {
+		t.Fatalf("got archive files %v, want one", files)
+	}
+	file, err := os.Open(files[0])
+	if err != nil {
+		t.Fatalf("open archive: %v", err)
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	scanner.Scan()
+	archived := retention.ArchivedTask{}
+	if err = json.Unmarshal(scanner.Bytes(), &archived); err != nil {
+		t.Fatalf("decode archive: %v", err)
+	}
+	if len(archived.ImageFiles) != 1 || len(archived.Outputs) != 2 {
+		t.Fatalf("got image files %v outputs %+v, want one image and two outputs", archived.ImageFiles, archived.Outputs)
+	}
+	content, err := os.ReadFile(filepath.Join(dir, archived.ImageFiles[0]))
+	if err != nil || !bytes.Equal(content, png) || filepath.Ext(archived.ImageFiles[0]) != ".png" {
+		t.Errorf("got image file %s %q, %v, want the decoded png", archived.ImageFiles[0], content, err)
+	}
+	content, err = os.ReadFile(filepath.Join(dir, archived.Outputs[1].File))
+	if err != nil || string(content) != "hello" || archived.Outputs[1].Data != nil {
+		t.Errorf("got output file %q, %v, want the text output", content, err)
+	}
+
+	output, err := repo.GetOutput(ctx, task.ID, 1)
+	if err != nil || output.Data != nil {
+		t.Errorf("got output %+v, %v, want the content purged", output, err)
+	}
+}